@@ -0,0 +1,215 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"fresherpaint/backend/models"
+)
+
+// maxDatasetBodyBytes caps the size of dataset create/update request bodies
+const maxDatasetBodyBytes = 32 << 20
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// idParam returns the {id} path parameter, answering 404 for resource when
+// it is not a UUID
+func idParam(w http.ResponseWriter, r *http.Request, resource string) (string, bool) {
+	id := r.PathValue("id")
+	if !uuidPattern.MatchString(id) {
+		writeError(w, notFound(resource))
+		return "", false
+	}
+	return id, true
+}
+
+// datasetHandler adapts a handler for /api/analytics/{id} and its
+// subresources, passing it the dataset ID
+func datasetHandler(handler func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := idParam(w, r, "Dataset"); ok {
+			handler(w, r, id)
+		}
+	}
+}
+
+func createAnalyticsDataHandler(w http.ResponseWriter, r *http.Request) {
+	var input models.AnalyticsDataInput
+	if err := decodeDatasetBody(w, r, &input); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+
+	if err := input.Validate(datasetTypes.Has); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if errs := validateDatasetPayload(input.DataType, input.Data); len(errs) > 0 {
+		writeError(w, schemaErrors(errs))
+		return
+	}
+
+	row := database.GetDB().QueryRow(`
+		INSERT INTO analytics_data (title, description, data_type, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING `+analyticsDataColumns,
+		input.Title, input.Description, input.DataType, []byte(input.Data),
+	)
+
+	item, err := scanAnalyticsData(row)
+	if err != nil {
+		writeError(w, datasetStoreError(err, &input.DataType, "Failed to create dataset"))
+		return
+	}
+
+	w.Header().Set("Location", "/api/analytics/"+item.ID)
+	writeJSON(w, http.StatusCreated, APIResponse{Success: true, Data: item})
+}
+
+func getAnalyticsDataByIDHandler(w http.ResponseWriter, r *http.Request, id string) {
+	row := database.GetDB().QueryRow("SELECT "+analyticsDataColumns+" FROM analytics_data WHERE id = $1", id)
+	writeDatasetRow(w, row, nil, "Failed to fetch dataset")
+}
+
+func replaceAnalyticsDataHandler(w http.ResponseWriter, r *http.Request, id string) {
+	var input models.AnalyticsDataInput
+	if err := decodeDatasetBody(w, r, &input); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+
+	if err := input.Validate(datasetTypes.Has); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if errs := validateDatasetPayload(input.DataType, input.Data); len(errs) > 0 {
+		writeError(w, schemaErrors(errs))
+		return
+	}
+
+	row := database.GetDB().QueryRow(`
+		UPDATE analytics_data
+		SET title = $2, description = $3, data_type = $4, data = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+analyticsDataColumns,
+		id, input.Title, input.Description, input.DataType, []byte(input.Data),
+	)
+	writeDatasetRow(w, row, &input.DataType, "Failed to update dataset")
+}
+
+func patchAnalyticsDataHandler(w http.ResponseWriter, r *http.Request, id string) {
+	var patch models.AnalyticsDataPatch
+	if err := decodeDatasetBody(w, r, &patch); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+
+	if err := patch.Validate(datasetTypes.Has); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// A new type or payload is validated together with the stored counterpart
+	if patch.DataType != nil || patch.Data != nil {
+		var storedType models.AnalyticsType
+		var storedData []byte
+		err := database.GetDB().QueryRow("SELECT data_type, data FROM analytics_data WHERE id = $1", id).Scan(&storedType, &storedData)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, notFound("Dataset"))
+			return
+		}
+		if err != nil {
+			writeError(w, internalError("Failed to fetch dataset", err))
+			return
+		}
+
+		if patch.DataType != nil {
+			storedType = *patch.DataType
+		}
+		if patch.Data != nil {
+			storedData = patch.Data
+		}
+
+		if errs := validateDatasetPayload(storedType, storedData); len(errs) > 0 {
+			writeError(w, schemaErrors(errs))
+			return
+		}
+	}
+
+	// NULL parameters keep the stored value
+	var data interface{}
+	if patch.Data != nil {
+		data = []byte(patch.Data)
+	}
+
+	row := database.GetDB().QueryRow(`
+		UPDATE analytics_data
+		SET title = COALESCE($2, title),
+			description = COALESCE($3, description),
+			data_type = COALESCE($4, data_type),
+			data = COALESCE($5::jsonb, data),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+analyticsDataColumns,
+		id, patch.Title, patch.Description, patch.DataType, data,
+	)
+	writeDatasetRow(w, row, patch.DataType, "Failed to update dataset")
+}
+
+func deleteAnalyticsDataHandler(w http.ResponseWriter, r *http.Request, id string) {
+	result, err := database.GetDB().Exec("DELETE FROM analytics_data WHERE id = $1", id)
+	if err != nil {
+		writeError(w, internalError("Failed to delete dataset", err))
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		writeError(w, notFound("Dataset"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]string{"id": id}})
+}
+
+// decodeDatasetBody decodes a size-limited JSON body, rejecting unknown fields
+func decodeDatasetBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDatasetBodyBytes))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// writeDatasetRow scans a single returned dataset and writes it, or 404 if no row matched
+func writeDatasetRow(w http.ResponseWriter, row *sql.Row, dataType *models.AnalyticsType, failure string) {
+	item, err := scanAnalyticsData(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, notFound("Dataset"))
+		return
+	}
+	if err != nil {
+		writeError(w, datasetStoreError(err, dataType, failure))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: item})
+}
+
+// loadDataset fetches a dataset for a subresource handler. It writes the
+// 404 or 500 response itself and returns false when the dataset is unusable.
+func loadDataset(w http.ResponseWriter, id string) (*models.AnalyticsData, bool) {
+	row := database.GetDB().QueryRow("SELECT "+analyticsDataColumns+" FROM analytics_data WHERE id = $1", id)
+	item, err := scanAnalyticsData(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, notFound("Dataset"))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to fetch dataset", err))
+		return nil, false
+	}
+	return item, true
+}
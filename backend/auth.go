@@ -1,221 +1,326 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+
+	"fresherpaint/backend/jwtkeys"
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/router"
 )
 
+// maxAuthBodyBytes caps the size of login, refresh and logout request bodies,
+// which are read before the client is authenticated
+const maxAuthBodyBytes = 4 << 10
+
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	JWTSecret    string
-	PasswordHash string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// DummyHash is compared against when the username does not exist, so a
+	// failed login takes as long whether or not the account exists
+	DummyHash string
 }
 
 // LoginRequest represents the login request payload
 type LoginRequest struct {
+	Username string `json:"username"`
 	Password string `json:"password"`
 }
 
-// LoginResponse represents the login response
-type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expires_at"`
-}
-
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID string `json:"user_id"`
+	UserID   string      `json:"user_id"`
+	Username string      `json:"username"`
+	Role     models.Role `json:"role"`
 	jwt.RegisteredClaims
 }
 
+// routeAccess is the minimum role a route requires: read applies to GET and
+// HEAD, write to every other method
+type routeAccess struct {
+	read  models.Role
+	write models.Role
+}
+
+var (
+	// anyUser admits every logged-in user, whatever the method
+	anyUser = routeAccess{read: models.RoleViewer, write: models.RoleViewer}
+	// editorWrites lets viewers read and editors change data
+	editorWrites = routeAccess{read: models.RoleViewer, write: models.RoleEditor}
+	// adminOnly restricts every method to admins
+	adminOnly = routeAccess{read: models.RoleAdmin, write: models.RoleAdmin}
+)
+
+// required returns the role needed for a request method
+func (a routeAccess) required(method string) models.Role {
+	if method == http.MethodGet || method == http.MethodHead {
+		return a.read
+	}
+	return a.write
+}
+
+type claimsContextKey struct{}
+
+// requestClaims returns the claims of the authenticated user of a request,
+// or nil on public routes
+func requestClaims(r *http.Request) *JWTClaims {
+	claims, _ := r.Context().Value(claimsContextKey{}).(*JWTClaims)
+	return claims
+}
+
 var authConfig *AuthConfig
 
+// signingKeys signs and verifies access tokens; see initSigningKeys
+var signingKeys *jwtkeys.Manager
+
 // InitializeAuth initializes the authentication system
 func InitializeAuth(config *Config) error {
-	// Use JWT secret from config, generate one if not provided
-	jwtSecret := config.JWTSecret
-	if jwtSecret == "" {
-		// Generate a random 32-byte secret
-		bytes := make([]byte, 32)
-		if _, err := rand.Read(bytes); err != nil {
-			return fmt.Errorf("failed to generate JWT secret: %w", err)
-		}
-		jwtSecret = hex.EncodeToString(bytes)
+	dummy := make([]byte, 16)
+	if _, err := rand.Read(dummy); err != nil {
+		return fmt.Errorf("failed to generate dummy password: %w", err)
 	}
-
-	// Use password from config
-	password := config.SitePassword
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	dummyHash, err := hashPassword(hex.EncodeToString(dummy))
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return err
 	}
 
 	authConfig = &AuthConfig{
-		JWTSecret:    jwtSecret,
-		PasswordHash: string(hashedPassword),
+		AccessTokenTTL:  config.AccessTokenTTL,
+		RefreshTokenTTL: config.RefreshTokenTTL,
+		DummyHash:       dummyHash,
 	}
 
 	return nil
 }
 
+// initSigningKeys loads the persisted JWT signing keys, creating the first
+// one if needed. Retired keys verify tokens for at least one access token
+// lifetime, so no token outlives the key that signed it.
+func initSigningKeys(config *Config) error {
+	overlap := config.JWTKeyOverlap
+	if overlap < config.AccessTokenTTL {
+		overlap = config.AccessTokenTTL
+	}
+
+	manager, err := jwtkeys.NewManager(database.GetDB(), jwtkeys.Config{
+		Algorithm:   config.JWTAlgorithm,
+		Secret:      config.JWTSecret,
+		RotateEvery: config.JWTKeyRotation,
+		Overlap:     overlap,
+	})
+	if err != nil {
+		return err
+	}
+	if err := manager.Load(); err != nil {
+		return err
+	}
+
+	signingKeys = manager
+	return nil
+}
+
 // loginHandler handles user authentication
 func loginHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var loginReq LoginRequest
+	if err := decodeAuthBody(w, r, &loginReq); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+	if loginReq.Username == "" || loginReq.Password == "" {
+		writeError(w, apiError(CodeBadRequest, "Username and password are required"))
 		return
 	}
 
-	var loginReq LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		response := APIResponse{
-			Success: false,
-			Error:   "Invalid request body",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+	// Back off repeated failures from one address and against one account,
+	// before spending a bcrypt comparison. Unknown usernames are tracked too,
+	// so the response does not reveal which accounts exist.
+	ip := clientIP(r)
+	account := strings.ToLower(strings.TrimSpace(loginReq.Username))
+	if wait := max(rateLimits.LoginIP.Blocked(ip), rateLimits.LoginAccount.Blocked(account)); wait > 0 {
+		countLogin(loginBlocked)
+		writeTooManyRequests(w, wait, "Too many failed login attempts, retry later")
+		return
+	}
+
+	user, err := findUserByUsername(loginReq.Username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		writeError(w, internalError("Failed to look up user", err))
 		return
 	}
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(authConfig.PasswordHash), []byte(loginReq.Password)); err != nil {
-		response := APIResponse{
-			Success: false,
-			Error:   "Invalid credentials",
+	hash := authConfig.DummyHash
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(loginReq.Password)); err != nil || user == nil {
+		countLogin(loginFailure)
+		rateLimits.LoginAccount.Failure(account)
+		if wait := rateLimits.LoginIP.Failure(ip); wait >= loginIPPolicy.LockoutDuration {
+			slog.Warn("Login locked after repeated failures", "ip", ip, "duration", wait, "username", account)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(response)
+		writeError(w, apiError(CodeUnauthorized, "Invalid credentials"))
+		return
+	}
+	rateLimits.LoginAccount.Success(account)
+	requestInfoFrom(r).UserID = user.ID
+	countLogin(loginSuccess)
+
+	tokens, err := issueTokens(user)
+	if err != nil {
+		writeError(w, internalError("Failed to generate token", err))
 		return
 	}
 
-	// Generate JWT token
-	expiresAt := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: tokens})
+}
+
+// decodeAuthBody decodes a small, size-limited JSON body on the public auth
+// endpoints
+func decodeAuthBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAuthBodyBytes)).Decode(v)
+}
+
+// signAccessToken issues a short-lived access token carrying the user's ID
+// and role, with a random jti so it can be revoked
+func signAccessToken(user *models.User) (string, time.Time, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(authConfig.AccessTokenTTL)
 	claims := &JWTClaims{
-		UserID: "fresherpaint_user",
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(jti),
+			Subject:   user.ID,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "fresherpaint",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(authConfig.JWTSecret))
+	signed, err := signingKeys.Sign(claims)
 	if err != nil {
-		response := APIResponse{
-			Success: false,
-			Error:   "Failed to generate token",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		return "", time.Time{}, err
 	}
+	return signed, expiresAt, nil
+}
 
-	loginResp := LoginResponse{
-		Token:     tokenString,
-		ExpiresAt: expiresAt.Unix(),
+// parseBearerToken validates the access token in the Authorization header.
+// The error is an *APIError reported to the client.
+func parseBearerToken(r *http.Request) (*JWTClaims, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, apiError(CodeUnauthorized, "Authorization header required")
 	}
 
-	response := APIResponse{
-		Success: true,
-		Data:    loginResp,
+	// Extract token from "Bearer <token>" format
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		countTokenFailure("access", "malformed")
+		return nil, apiError(CodeUnauthorized, "Invalid authorization header format")
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-}
-
-// authMiddleware validates JWT tokens for protected routes
-func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			response := APIResponse{
-				Success: false,
-				Error:   "Authorization header required",
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(response)
-			return
-		}
-
-		// Extract token from "Bearer <token>" format
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			response := APIResponse{
-				Success: false,
-				Error:   "Invalid authorization header format",
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(response)
-			return
-		}
+	// Parse and validate token
+	claims := &JWTClaims{}
+	token, err := jwt.ParseWithClaims(tokenParts[1], claims, signingKeys.Keyfunc,
+		jwt.WithValidMethods(jwtkeys.Algorithms))
+	if err != nil {
+		countTokenFailure("access", tokenFailureReason(err))
+		return nil, apiError(CodeInvalidToken, "Invalid token")
+	}
 
-		tokenString := tokenParts[1]
+	if !token.Valid || claims.UserID == "" || claims.ID == "" {
+		countTokenFailure("access", "invalid")
+		return nil, apiError(CodeInvalidToken, "Token is not valid")
+	}
+	if revocations.IsRevoked(claims.ID) || claims.IssuedAt == nil ||
+		revocations.IsRevokedForUser(claims.UserID, claims.IssuedAt.Time) {
+		countTokenFailure("access", "revoked")
+		return nil, apiError(CodeInvalidToken, "Token has been revoked")
+	}
+	return claims, nil
+}
 
-		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(authConfig.JWTSecret), nil
-		})
+// tokenFailureReason names why jwt rejected a token, for
+// auth_token_validation_failures_total
+func tokenFailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "signature"
+	default:
+		return "invalid"
+	}
+}
 
-		if err != nil {
-			response := APIResponse{
-				Success: false,
-				Error:   "Invalid token",
+// authMiddleware validates JWT tokens for protected routes, rejects revoked
+// tokens and checks that the token's role meets the route's requirement for
+// the request method.
+// The claims are available to the handler through requestClaims.
+func authMiddleware(access routeAccess) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := parseBearerToken(r)
+			if err != nil {
+				writeError(w, err)
+				return
 			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(response)
-			return
-		}
+			requestInfoFrom(r).UserID = claims.UserID
 
-		if !token.Valid {
-			response := APIResponse{
-				Success: false,
-				Error:   "Token is not valid",
+			required := access.required(r.Method)
+			if !claims.Role.Allows(required) {
+				writeError(w, apiError(CodeForbidden, "This action requires the %s role", required))
+				return
 			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(response)
-			return
-		}
 
-		// Token is valid, proceed to the next handler
-		next(w, r)
+			// Token is valid, proceed to the next handler
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+		})
 	}
 }
 
-// verifyTokenHandler verifies if a token is still valid
-func verifyTokenHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// jwksHandler serves GET /.well-known/jwks.json, the public keys that verify
+// access tokens
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(signingKeys.JWKS())
+}
 
+// verifyTokenHandler verifies if a token is still valid and reports whom it
+// belongs to
+func verifyTokenHandler(w http.ResponseWriter, r *http.Request) {
 	// If we reach here, the token is valid (verified by authMiddleware)
-	response := APIResponse{
+	claims := requestClaims(r)
+	writeJSON(w, http.StatusOK, APIResponse{
 		Success: true,
-		Data:    map[string]string{"status": "valid"},
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+		Data: map[string]string{
+			"status":   "valid",
+			"user_id":  claims.UserID,
+			"username": claims.Username,
+			"role":     string(claims.Role),
+		},
+	})
 }
@@ -0,0 +1,157 @@
+package bell
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// quantum returns ideal polarisation correlations E(θ) = cos 2θ at the angles
+func quantum(sigma float64, angles ...float64) []Measurement {
+	measurements := make([]Measurement, len(angles))
+	for i, angle := range angles {
+		measurements[i] = Measurement{
+			Angle:            angle,
+			Correlation:      math.Cos(2 * angle * math.Pi / 180),
+			StatisticalError: sigma,
+		}
+	}
+	return measurements
+}
+
+func TestCHSH(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []Measurement
+		angle    float64
+		s        float64
+		err      float64
+		violates bool
+	}{
+		{
+			name:     "Tsirelson bound at 22.5°",
+			input:    quantum(0.01, 0, 22.5, 45, 67.5, 90),
+			angle:    22.5,
+			s:        TsirelsonBound,
+			err:      math.Sqrt(9*0.01*0.01 + 0.01*0.01),
+			violates: true,
+		},
+		{
+			name:     "angles are folded into [0°, 90°]",
+			input:    quantum(0.01, -22.5, 247.5),
+			angle:    22.5,
+			s:        TsirelsonBound,
+			err:      math.Sqrt(10) * 0.01,
+			violates: true,
+		},
+		{
+			name:     "the angle nearest the optimum is chosen",
+			input:    quantum(0.01, 10, 30, 20, 60),
+			angle:    20,
+			s:        math.Abs(3*math.Cos(40*math.Pi/180) - math.Cos(120*math.Pi/180)),
+			err:      math.Sqrt(10) * 0.01,
+			violates: true,
+		},
+		{
+			name: "local model at the classical bound",
+			input: []Measurement{
+				{Angle: 22.5, Correlation: 0.5, StatisticalError: 0.01},
+				{Angle: 67.5, Correlation: -0.5, StatisticalError: 0.01},
+			},
+			angle: 22.5,
+			s:     ClassicalBound,
+			err:   math.Sqrt(10) * 0.01,
+		},
+		{
+			name: "repeated angles use the weighted mean",
+			input: []Measurement{
+				{Angle: 22.5, Correlation: 0.6, StatisticalError: 0.1},
+				{Angle: 22.5, Correlation: 0.9, StatisticalError: 0.2},
+				{Angle: 67.5, Correlation: -0.7, StatisticalError: 0.1},
+			},
+			angle:    22.5,
+			s:        3*(0.6*100+0.9*25)/125 + 0.7,
+			err:      math.Sqrt(9/125.0 + 0.01),
+			violates: true,
+		},
+		{
+			name: "binomial error from the measurement count",
+			input: []Measurement{
+				{Angle: 22.5, Correlation: 0.6, MeasurementCount: 100},
+				{Angle: 67.5, Correlation: -0.8, MeasurementCount: 100},
+			},
+			angle:    22.5,
+			s:        2.6,
+			err:      math.Sqrt(9*0.64/100 + 0.36/100),
+			violates: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CHSH(tt.input)
+			if err != nil {
+				t.Fatalf("CHSH: %v", err)
+			}
+			if result.Angle != tt.angle {
+				t.Errorf("angle = %g, want %g", result.Angle, tt.angle)
+			}
+			if math.Abs(result.S-tt.s) > 1e-9 {
+				t.Errorf("S = %.12f, want %.12f", result.S, tt.s)
+			}
+			if math.Abs(result.Error-tt.err) > 1e-9 {
+				t.Errorf("error = %g, want %g", result.Error, tt.err)
+			}
+			if result.Violates != tt.violates {
+				t.Errorf("violates = %t, want %t", result.Violates, tt.violates)
+			}
+			if want := (result.S - ClassicalBound) / result.Error; math.Abs(result.Sigma-want) > 1e-9 {
+				t.Errorf("sigma = %g, want %g", result.Sigma, want)
+			}
+		})
+	}
+}
+
+func TestCHSHRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []Measurement
+		want  string
+	}{
+		{"no measurements", nil, "no measurements"},
+		{"no 3θ partner", quantum(0.01, 22.5, 45), "cannot be formed"},
+		{"only the fixed point 0°", quantum(0.01, 0, 180), "cannot be formed"},
+		{"correlation out of range", []Measurement{{Angle: 0, Correlation: 1.5, StatisticalError: 0.1}}, "between -1 and 1"},
+		{"no error or count", []Measurement{{Angle: 0, Correlation: 0.5}}, "statistical_error or measurement_count"},
+		{"infinite angle", []Measurement{{Angle: math.Inf(1), Correlation: 0.5, StatisticalError: 0.1}}, "finite"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CHSH(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct{ in, want float64 }{
+		{0, 0},
+		{22.5, 22.5},
+		{-22.5, 22.5},
+		{157.5, 22.5},
+		{202.5, 22.5},
+		{247.5, 67.5},
+		{90, 90},
+		{-450, 90},
+		{0.1 + 0.2, 0.3},
+	}
+
+	for _, tt := range tests {
+		if got := fold(tt.in); got != tt.want {
+			t.Errorf("fold(%g) = %g, want %g", tt.in, got, tt.want)
+		}
+	}
+}
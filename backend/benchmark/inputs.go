@@ -0,0 +1,58 @@
+package benchmark
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Input distributions
+const (
+	Random    = "random"
+	Sorted    = "sorted"
+	Reversed  = "reversed"
+	FewUnique = "few_unique"
+)
+
+// Distributions lists the supported input distributions
+var Distributions = []string{Random, Sorted, Reversed, FewUnique}
+
+// fewUniqueValues is how many distinct keys a few_unique input draws from
+const fewUniqueValues = 16
+
+// generateInput returns n keys drawn from the named distribution. Sorted and
+// reversed inputs are random keys put in order rather than 0..n-1, so every
+// distribution has the same key range and RadixSort does the same passes.
+func generateInput(distribution string, n int, rng *rand.Rand) []int32 {
+	a := make([]int32, n)
+	switch distribution {
+	case FewUnique:
+		values := make([]int32, fewUniqueValues)
+		for i := range values {
+			values[i] = int32(rng.Uint32())
+		}
+		for i := range a {
+			a[i] = values[rng.Intn(len(values))]
+		}
+	default:
+		for i := range a {
+			a[i] = int32(rng.Uint32())
+		}
+	}
+
+	switch distribution {
+	case Sorted:
+		sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	case Reversed:
+		sort.Slice(a, func(i, j int) bool { return a[i] > a[j] })
+	}
+	return a
+}
+
+func isDistribution(name string) bool {
+	for _, d := range Distributions {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+// Package benchmark measures sorting algorithms on generated inputs. It
+// times each algorithm over repeated runs for a grid of input sizes and
+// distributions and reports the median with its spread, together with the
+// host the measurements were taken on.
+package benchmark
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fresherpaint/backend/stats"
+)
+
+// Limits on a single run, so one request cannot occupy the server for long.
+// A run at the limits sorts for a few seconds.
+const (
+	MaxSize        = 1000000
+	MaxRepetitions = 50
+	// MaxElements bounds the total number of keys sorted across the run
+	MaxElements = 10000000
+)
+
+// Algorithm is one benchmarked sort
+type Algorithm struct {
+	Name       string
+	Complexity string // claimed complexity, as stored in the dataset
+	Sort       func([]int32)
+}
+
+// Algorithms lists the benchmarked sorts in their default order
+var Algorithms = []Algorithm{
+	{"QuickSort", "O(n log n) avg, O(n²) worst", QuickSort},
+	{"MergeSort", "O(n log n)", MergeSort},
+	{"TimSort", "O(n) to O(n log n)", TimSort},
+	{"RadixSort", "O(kn)", RadixSort},
+}
+
+// Config selects what to measure. Empty Algorithms or Distributions select
+// all of them.
+type Config struct {
+	Sizes         []int    `json:"sizes"`
+	Distributions []string `json:"distributions"`
+	Algorithms    []string `json:"algorithms"`
+	Repetitions   int      `json:"repetitions"`
+	Seed          int64    `json:"seed"`
+}
+
+// DefaultConfig is a run small enough to finish in well under a second
+func DefaultConfig() Config {
+	return Config{
+		Sizes:         []int{1000, 5000, 10000, 25000, 50000, 100000},
+		Distributions: []string{Random},
+		Repetitions:   5,
+		Seed:          1,
+	}
+}
+
+// Validate fills in defaults and checks the configuration against the limits
+func (c *Config) Validate() error {
+	if len(c.Sizes) == 0 {
+		return fmt.Errorf("sizes must list at least one input size")
+	}
+	seen := map[int]bool{}
+	for _, n := range c.Sizes {
+		if n < 1 || n > MaxSize {
+			return fmt.Errorf("sizes must be between 1 and %d", MaxSize)
+		}
+		if seen[n] {
+			return fmt.Errorf("size %d is listed twice", n)
+		}
+		seen[n] = true
+	}
+	sort.Ints(c.Sizes)
+
+	if len(c.Distributions) == 0 {
+		c.Distributions = append([]string(nil), Distributions...)
+	}
+	for _, d := range c.Distributions {
+		if !isDistribution(d) {
+			return fmt.Errorf("unknown distribution %q (available: %s)", d, strings.Join(Distributions, ", "))
+		}
+	}
+
+	if len(c.Algorithms) == 0 {
+		for _, a := range Algorithms {
+			c.Algorithms = append(c.Algorithms, a.Name)
+		}
+	}
+	for _, name := range c.Algorithms {
+		if _, ok := lookup(name); !ok {
+			return fmt.Errorf("unknown algorithm %q (available: %s)", name, strings.Join(algorithmNames(), ", "))
+		}
+	}
+
+	if c.Repetitions == 0 {
+		c.Repetitions = DefaultConfig().Repetitions
+	}
+	if c.Repetitions < 1 || c.Repetitions > MaxRepetitions {
+		return fmt.Errorf("repetitions must be between 1 and %d", MaxRepetitions)
+	}
+
+	var total int
+	for _, n := range c.Sizes {
+		total += n
+	}
+	if float64(total)*float64(len(c.Distributions)*len(c.Algorithms)*c.Repetitions) > MaxElements {
+		return fmt.Errorf("the run would sort more than %d keys in total; reduce sizes, distributions, algorithms or repetitions", MaxElements)
+	}
+	return nil
+}
+
+// Series is the measurements of one algorithm on one distribution. Runtimes
+// are in milliseconds, one entry per input size.
+type Series struct {
+	Name         string    `json:"name"`
+	Distribution string    `json:"distribution"`
+	Complexity   string    `json:"complexity"`
+	InputSize    []int     `json:"input_size"`
+	Runtime      []float64 `json:"runtime"` // median
+	RuntimeMin   []float64 `json:"runtime_min"`
+	RuntimeMax   []float64 `json:"runtime_max"`
+	RuntimeIQR   []float64 `json:"runtime_iqr"`
+	Repetitions  int       `json:"repetitions"`
+}
+
+// Result is a complete benchmark run
+type Result struct {
+	Config      Config            `json:"benchmark"`
+	Environment map[string]string `json:"test_environment"`
+	Series      []Series          `json:"algorithms"`
+	RuntimeUnit string            `json:"runtime_unit"`
+	StartedAt   time.Time         `json:"started_at"`
+	Duration    time.Duration     `json:"-"`
+}
+
+// ErrBusy is returned by Run while another run is in progress
+var ErrBusy = errors.New("a benchmark is already running")
+
+// running serializes runs: concurrent runs would compete for the CPU and
+// distort each other's timings
+var running sync.Mutex
+
+// Run measures every selected algorithm on every distribution and size.
+//
+// Each (distribution, size) input is generated once from the seed, and every
+// repetition sorts a fresh copy of it, so all algorithms see identical keys.
+// The garbage collector runs before each timed sort so earlier allocations
+// are not collected on the clock. The first repetition's output is checked,
+// and a sort that leaves its input unordered fails the run.
+func Run(config Config) (*Result, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if !running.TryLock() {
+		return nil, ErrBusy
+	}
+	defer running.Unlock()
+
+	result := &Result{
+		Config:      config,
+		Environment: Environment(),
+		RuntimeUnit: "ms",
+		StartedAt:   time.Now().UTC(),
+	}
+	rng := rand.New(rand.NewSource(config.Seed))
+
+	index := map[[2]string]int{}
+	for _, d := range config.Distributions {
+		for _, name := range config.Algorithms {
+			algorithm, _ := lookup(name)
+			index[[2]string{d, name}] = len(result.Series)
+			result.Series = append(result.Series, Series{
+				Name:         algorithm.Name,
+				Distribution: d,
+				Complexity:   algorithm.Complexity,
+				InputSize:    append([]int(nil), config.Sizes...),
+				Repetitions:  config.Repetitions,
+			})
+		}
+	}
+
+	timings := make([]float64, config.Repetitions)
+	for _, d := range config.Distributions {
+		for _, n := range config.Sizes {
+			input := generateInput(d, n, rng)
+			work := make([]int32, n)
+
+			for _, name := range config.Algorithms {
+				algorithm, _ := lookup(name)
+				for rep := range timings {
+					copy(work, input)
+					runtime.GC()
+					start := time.Now()
+					algorithm.Sort(work)
+					timings[rep] = float64(time.Since(start).Nanoseconds()) / 1e6
+					if rep == 0 && !isSorted(work) {
+						return nil, fmt.Errorf("%s left a %s input of %d keys unsorted", name, d, n)
+					}
+				}
+
+				sort.Float64s(timings)
+				s := &result.Series[index[[2]string{d, name}]]
+				s.Runtime = append(s.Runtime, roundMs(stats.Quantile(timings, 0.5)))
+				s.RuntimeMin = append(s.RuntimeMin, roundMs(timings[0]))
+				s.RuntimeMax = append(s.RuntimeMax, roundMs(timings[len(timings)-1]))
+				s.RuntimeIQR = append(s.RuntimeIQR, roundMs(stats.Quantile(timings, 0.75)-stats.Quantile(timings, 0.25)))
+			}
+		}
+	}
+
+	result.Duration = time.Since(result.StartedAt)
+	return result, nil
+}
+
+// roundMs keeps microsecond resolution
+func roundMs(ms float64) float64 {
+	return math.Round(ms*1000) / 1000
+}
+
+func isSorted(a []int32) bool {
+	for i := 1; i < len(a); i++ {
+		if a[i] < a[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+func lookup(name string) (Algorithm, bool) {
+	for _, a := range Algorithms {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return Algorithm{}, false
+}
+
+func algorithmNames() []string {
+	names := make([]string, len(Algorithms))
+	for i, a := range Algorithms {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// Environment describes the host: CPU model, logical CPUs, Go version and
+// platform. The CPU model is read from /proc/cpuinfo and is "unknown" where
+// that is unavailable.
+func Environment() map[string]string {
+	return map[string]string{
+		"cpu":        cpuModel(),
+		"cpu_cores":  strconv.Itoa(runtime.NumCPU()),
+		"gomaxprocs": strconv.Itoa(runtime.GOMAXPROCS(0)),
+		"go_version": runtime.Version(),
+		"compiler":   runtime.Compiler,
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+}
+
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		// x86 reports "model name"; some ARM kernels only report "Hardware"
+		switch strings.TrimSpace(key) {
+		case "model name", "Hardware", "cpu model":
+			if v := strings.TrimSpace(value); v != "" {
+				return v
+			}
+		}
+	}
+	return "unknown"
+}
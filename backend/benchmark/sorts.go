@@ -0,0 +1,231 @@
+package benchmark
+
+// The sorts below work on []int32 so RadixSort can use fixed-width keys.
+// Each one sorts in place in ascending order.
+
+// insertionThreshold is the slice length below which the comparison sorts
+// switch to insertion sort
+const insertionThreshold = 24
+
+func insertionSort(a []int32) {
+	for i := 1; i < len(a); i++ {
+		v := a[i]
+		j := i
+		for j > 0 && a[j-1] > v {
+			a[j] = a[j-1]
+			j--
+		}
+		a[j] = v
+	}
+}
+
+// QuickSort is Hoare-partition quicksort with a median-of-three pivot,
+// recursing into the smaller side so the stack stays O(log n)
+func QuickSort(a []int32) {
+	for len(a) > insertionThreshold {
+		mid := len(a) / 2
+		last := len(a) - 1
+		if a[mid] < a[0] {
+			a[mid], a[0] = a[0], a[mid]
+		}
+		if a[last] < a[0] {
+			a[last], a[0] = a[0], a[last]
+		}
+		if a[last] < a[mid] {
+			a[last], a[mid] = a[mid], a[last]
+		}
+		pivot := a[mid]
+
+		i, j := -1, len(a)
+		for {
+			for i++; a[i] < pivot; i++ {
+			}
+			for j--; a[j] > pivot; j-- {
+			}
+			if i >= j {
+				break
+			}
+			a[i], a[j] = a[j], a[i]
+		}
+
+		if j+1 < len(a)-j-1 {
+			QuickSort(a[:j+1])
+			a = a[j+1:]
+		} else {
+			QuickSort(a[j+1:])
+			a = a[:j+1]
+		}
+	}
+	insertionSort(a)
+}
+
+// MergeSort is a top-down stable merge sort using one scratch buffer
+func MergeSort(a []int32) {
+	buf := make([]int32, len(a))
+	mergeSort(a, buf)
+}
+
+func mergeSort(a, buf []int32) {
+	if len(a) <= insertionThreshold {
+		insertionSort(a)
+		return
+	}
+	mid := len(a) / 2
+	mergeSort(a[:mid], buf[:mid])
+	mergeSort(a[mid:], buf[mid:])
+	if a[mid-1] <= a[mid] {
+		return // already in order
+	}
+	merge(a, mid, buf)
+}
+
+// merge merges the sorted halves a[:mid] and a[mid:] using buf
+func merge(a []int32, mid int, buf []int32) {
+	copy(buf, a[:mid])
+	left := buf[:mid]
+	i, j, k := 0, mid, 0
+	for i < len(left) && j < len(a) {
+		if a[j] < left[i] {
+			a[k] = a[j]
+			j++
+		} else {
+			a[k] = left[i]
+			i++
+		}
+		k++
+	}
+	copy(a[k:], left[i:])
+}
+
+// TimSort is a simplified TimSort: it finds natural runs, reversing
+// descending ones, extends short runs to a minimum length with insertion
+// sort and merges runs from a stack that keeps their lengths balanced. It
+// omits galloping, so it shows TimSort's linear behaviour on presorted data
+// without its full constant-factor tuning.
+func TimSort(a []int32) {
+	n := len(a)
+	if n < 2 {
+		return
+	}
+	minRun := timMinRun(n)
+	buf := make([]int32, n/2+1)
+
+	type run struct{ start, length int }
+	var runs []run
+
+	mergeAt := func(i int) {
+		x, y := runs[i], runs[i+1]
+		merge(a[x.start:y.start+y.length], x.length, growBuffer(&buf, x.length))
+		runs[i] = run{x.start, x.length + y.length}
+		runs = append(runs[:i+1], runs[i+2:]...)
+	}
+
+	for lo := 0; lo < n; {
+		hi := lo + 1
+		if hi < n {
+			if a[hi] < a[lo] {
+				for hi+1 < n && a[hi+1] < a[hi] {
+					hi++
+				}
+				for i, j := lo, hi; i < j; i, j = i+1, j-1 {
+					a[i], a[j] = a[j], a[i]
+				}
+			} else {
+				for hi+1 < n && a[hi+1] >= a[hi] {
+					hi++
+				}
+			}
+		}
+		length := hi - lo + 1
+		if length < minRun {
+			length = minRun
+			if lo+length > n {
+				length = n - lo
+			}
+			insertionSort(a[lo : lo+length])
+		}
+		runs = append(runs, run{lo, length})
+		lo += length
+
+		// Keep run lengths decreasing like Fibonacci numbers
+		for len(runs) > 1 {
+			k := len(runs) - 1
+			switch {
+			case k >= 2 && runs[k-2].length <= runs[k-1].length+runs[k].length:
+				if runs[k-2].length < runs[k].length {
+					mergeAt(k - 2)
+				} else {
+					mergeAt(k - 1)
+				}
+			case runs[k-1].length <= runs[k].length:
+				mergeAt(k - 1)
+			default:
+				k = 0
+			}
+			if k == 0 {
+				break
+			}
+		}
+	}
+
+	for len(runs) > 1 {
+		mergeAt(len(runs) - 2)
+	}
+}
+
+// timMinRun picks a run length in [32, 64] so n/minRun is close to a power of two
+func timMinRun(n int) int {
+	r := 0
+	for n >= 64 {
+		r |= n & 1
+		n >>= 1
+	}
+	return n + r
+}
+
+func growBuffer(buf *[]int32, n int) []int32 {
+	if cap(*buf) < n {
+		*buf = make([]int32, n)
+	}
+	return (*buf)[:n]
+}
+
+// RadixSort is an LSD radix sort over the four bytes of each key, with the
+// sign bit flipped so negative keys order first. Passes where every key has
+// the same byte are skipped.
+func RadixSort(a []int32) {
+	n := len(a)
+	if n < 2 {
+		return
+	}
+	src := a
+	dst := make([]int32, n)
+	for shift := uint(0); shift < 32; shift += 8 {
+		var counts [256]int
+		for _, v := range src {
+			counts[radixByte(v, shift)]++
+		}
+		if counts[radixByte(src[0], shift)] == n {
+			continue
+		}
+
+		offset := 0
+		for i, c := range counts {
+			counts[i] = offset
+			offset += c
+		}
+		for _, v := range src {
+			b := radixByte(v, shift)
+			dst[counts[b]] = v
+			counts[b]++
+		}
+		src, dst = dst, src
+	}
+	if &src[0] != &a[0] {
+		copy(a, src)
+	}
+}
+
+func radixByte(v int32, shift uint) byte {
+	return byte((uint32(v) ^ 0x80000000) >> shift)
+}
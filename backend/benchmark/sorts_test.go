@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// checkSort sorts a copy of input with algorithm and compares it against the
+// standard library, which checks both order and that no key was lost
+func checkSort(t *testing.T, algorithm Algorithm, input []int32) {
+	t.Helper()
+	got := slices.Clone(input)
+	algorithm.Sort(got)
+
+	want := slices.Clone(input)
+	slices.Sort(want)
+	if !isSorted(got) {
+		t.Fatalf("%s: output is not sorted", algorithm.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("%s: output is not a permutation of the input", algorithm.Name)
+	}
+}
+
+func TestSortsByDistribution(t *testing.T) {
+	// Sizes around insertionThreshold, the TimSort minimum run length and
+	// the point where timMinRun starts halving n
+	sizes := []int{0, 1, 2, 3, insertionThreshold - 1, insertionThreshold, insertionThreshold + 1,
+		31, 32, 33, 63, 64, 65, 127, 128, 129, 1000, 4097}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, distribution := range Distributions {
+		for _, n := range sizes {
+			input := generateInput(distribution, n, rng)
+			t.Run(fmt.Sprintf("%s/%d", distribution, n), func(t *testing.T) {
+				for _, algorithm := range Algorithms {
+					checkSort(t, algorithm, input)
+				}
+			})
+		}
+	}
+}
+
+func TestSortsEdgeCases(t *testing.T) {
+	sawtooth := make([]int32, 500)
+	for i := range sawtooth {
+		sawtooth[i] = int32(i % 37)
+	}
+	// ascending and descending runs of varying length exercise the TimSort
+	// run stack and the descending-run reversal
+	runs := make([]int32, 0, 3000)
+	for length := 1; len(runs) < 2900; length += 13 {
+		for i := 0; i < length; i++ {
+			if length%2 == 0 {
+				runs = append(runs, int32(i))
+			} else {
+				runs = append(runs, int32(length-i))
+			}
+		}
+	}
+	organPipe := make([]int32, 301)
+	for i := range organPipe {
+		organPipe[i] = int32(150 - max(i-150, 150-i))
+	}
+
+	tests := []struct {
+		name  string
+		input []int32
+	}{
+		{"all equal", slices.Repeat([]int32{7}, 200)},
+		{"two values", []int32{1, 0}},
+		{"extremes and signs", []int32{math.MaxInt32, -1, 0, math.MinInt32, 1, math.MinInt32 + 1, math.MaxInt32 - 1}},
+		{"negative only", []int32{-5, -300, -70000, -1, -2_000_000_000}},
+		{"differs only in the high byte", []int32{3 << 24, 1 << 24, -1 << 24, 2 << 24, 0}},
+		{"sawtooth", sawtooth},
+		{"natural runs", runs},
+		{"organ pipe", organPipe},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, algorithm := range Algorithms {
+				checkSort(t, algorithm, tt.input)
+			}
+		})
+	}
+}
+
+func TestTimMinRun(t *testing.T) {
+	tests := []struct{ n, want int }{
+		{1, 1},
+		{63, 63},
+		{64, 32},
+		{65, 33},
+		{128, 32},
+		{129, 33},
+		{1000, 63},
+	}
+
+	for _, tt := range tests {
+		if got := timMinRun(tt.n); got != tt.want {
+			t.Errorf("timMinRun(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+
+	"fresherpaint/backend/bell"
+)
+
+// chshHandler serves GET /api/analytics/{id}/chsh, the CHSH parameter S
+// computed from the dataset's quantum_measurements
+func chshHandler(w http.ResponseWriter, r *http.Request, id string) {
+	item, ok := loadDataset(w, id)
+	if !ok {
+		return
+	}
+
+	measurements, err := bell.Measurements(item.Data)
+	if err != nil {
+		writeError(w, apiError(CodeUnprocessable, "%v", err))
+		return
+	}
+
+	result, err := bell.CHSH(measurements)
+	if err != nil {
+		writeError(w, apiError(CodeUnprocessable, "%v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: result})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"fresherpaint/backend/complexity"
+)
+
+// AlgorithmComplexity is the complexity analysis of one benchmarked algorithm
+type AlgorithmComplexity struct {
+	Name         string             `json:"name"`
+	Distribution string             `json:"distribution,omitempty"`
+	Claimed      string             `json:"claimed,omitempty"`
+	Best         string             `json:"best,omitempty"`
+	Consistent   *bool              `json:"consistent_with_claim,omitempty"`
+	Analysis     *complexity.Result `json:"analysis,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// benchmarkAlgorithm is an entry of the algorithms array of a benchmark payload
+type benchmarkAlgorithm struct {
+	Name         string    `json:"name"`
+	Distribution string    `json:"distribution"`
+	Runtime      []float64 `json:"runtime"`
+	InputSize    []float64 `json:"input_size"`
+	Complexity   string    `json:"complexity"`
+}
+
+// complexityHandler serves GET /api/analytics/{id}/complexity. Each
+// algorithm's runtime vs input_size series is fitted against O(n),
+// O(n log n), O(n²) and O(kn) and compared with its claimed complexity.
+// radix sets the digit base used for the k of O(kn).
+func complexityHandler(w http.ResponseWriter, r *http.Request, id string) {
+	opts := complexity.Options{Radix: complexity.DefaultRadix}
+	if raw := r.URL.Query().Get("radix"); raw != "" {
+		radix, err := strconv.Atoi(raw)
+		if err != nil || radix < 2 || radix > 1<<16 {
+			writeError(w, apiError(CodeBadRequest, "radix must be an integer between 2 and 65536"))
+			return
+		}
+		opts.Radix = radix
+	}
+
+	item, ok := loadDataset(w, id)
+	if !ok {
+		return
+	}
+
+	payload, _ := item.Data.(map[string]interface{})
+	raw, ok := payload["algorithms"]
+	if !ok {
+		writeError(w, apiError(CodeUnprocessable, "dataset has no algorithms"))
+		return
+	}
+	encoded, _ := json.Marshal(raw)
+	var algorithms []benchmarkAlgorithm
+	if err := json.Unmarshal(encoded, &algorithms); err != nil {
+		writeError(w, apiError(CodeUnprocessable, "algorithms: %v", err))
+		return
+	}
+
+	results := make([]AlgorithmComplexity, len(algorithms))
+	for i, algorithm := range algorithms {
+		results[i] = AlgorithmComplexity{
+			Name:         algorithm.Name,
+			Distribution: algorithm.Distribution,
+			Claimed:      algorithm.Complexity,
+		}
+
+		analysis, err := complexity.Analyze(algorithm.InputSize, algorithm.Runtime, opts)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Analysis = analysis
+		results[i].Best = analysis.Best
+
+		if claims := complexity.Claims(algorithm.Complexity); len(claims) > 0 {
+			consistent := complexity.Consistent(analysis.Best, claims)
+			results[i].Consistent = &consistent
+		}
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: results})
+}
@@ -0,0 +1,200 @@
+// Package complexity fits measured runtimes against the growth laws of
+// common complexity classes and ranks them, so the complexity claimed for
+// an algorithm can be checked against its benchmark data.
+package complexity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Model names
+const (
+	Linear       = "O(n)"
+	Linearithmic = "O(n log n)"
+	Quadratic    = "O(n²)"
+	DigitLinear  = "O(kn)"
+)
+
+// DefaultRadix is the digit base assumed for the k of O(kn)
+const DefaultRadix = 10
+
+// minPoints is the smallest series fitted; AICc needs more points than
+// parameters plus one
+const minPoints = 4
+
+// Options configures the candidate models
+type Options struct {
+	// Radix is the digit base of O(kn): keys are assumed to range up to n,
+	// so k, the number of digits, grows as ⌈log_radix n⌉
+	Radix int
+}
+
+// Fit is one candidate model t = intercept + coefficient·g(n) fitted to a series
+type Fit struct {
+	Model        string  `json:"model"`
+	Intercept    float64 `json:"intercept"`
+	Coefficient  float64 `json:"coefficient"`
+	RSquared     float64 `json:"r_squared"`
+	AIC          float64 `json:"aic"`
+	AICc         float64 `json:"aicc"`
+	AkaikeWeight float64 `json:"akaike_weight"`
+}
+
+// Result ranks the candidate models for one series, best first
+type Result struct {
+	Best   string `json:"best"`
+	Points int    `json:"points"`
+	Fits   []Fit  `json:"fits"`
+}
+
+type candidate struct {
+	name   string
+	growth func(n float64) float64
+}
+
+func candidates(opts Options) []candidate {
+	radix := float64(opts.Radix)
+	if opts.Radix < 2 {
+		radix = DefaultRadix
+	}
+	return []candidate{
+		{Linear, func(n float64) float64 { return n }},
+		{Linearithmic, func(n float64) float64 { return n * math.Log2(n) }},
+		{Quadratic, func(n float64) float64 { return n * n }},
+		{DigitLinear, func(n float64) float64 {
+			digits := math.Ceil(math.Log(math.Max(n, 2)) / math.Log(radix))
+			return digits * n
+		}},
+	}
+}
+
+// Analyze fits runtimes measured at the given input sizes with each
+// candidate model by weighted least squares and ranks them by AICc.
+//
+// Weights are 1/t², so every point counts by its relative rather than
+// absolute deviation; otherwise the largest inputs alone would decide the
+// fit. R² and AIC are computed from the same weighted residuals, and the
+// Akaike weights give the relative support for each model.
+func Analyze(sizes, runtimes []float64, opts Options) (*Result, error) {
+	if len(sizes) != len(runtimes) {
+		return nil, fmt.Errorf("%d input sizes for %d runtimes", len(sizes), len(runtimes))
+	}
+	if len(sizes) < minPoints {
+		return nil, fmt.Errorf("at least %d points are needed, got %d", minPoints, len(sizes))
+	}
+	for i := range sizes {
+		if !(sizes[i] >= 1) || math.IsInf(sizes[i], 0) {
+			return nil, fmt.Errorf("input sizes must be at least 1")
+		}
+		if !(runtimes[i] > 0) || math.IsInf(runtimes[i], 0) {
+			return nil, fmt.Errorf("runtimes must be positive")
+		}
+	}
+
+	m := float64(len(sizes))
+	const params = 2.0
+	result := &Result{Points: len(sizes)}
+	for _, c := range candidates(opts) {
+		x := make([]float64, len(sizes))
+		for i, n := range sizes {
+			x[i] = c.growth(n)
+		}
+
+		intercept, coefficient, rss, tss := weightedLine(x, runtimes)
+		fit := Fit{Model: c.name, Intercept: intercept, Coefficient: coefficient}
+		if tss > 0 {
+			fit.RSquared = 1 - rss/tss
+		}
+		// Guard the log against a perfect fit
+		fit.AIC = m*math.Log(math.Max(rss/m, 1e-300)) + 2*params
+		fit.AICc = fit.AIC + 2*params*(params+1)/(m-params-1)
+		result.Fits = append(result.Fits, fit)
+	}
+
+	sort.SliceStable(result.Fits, func(i, j int) bool { return result.Fits[i].AICc < result.Fits[j].AICc })
+
+	var total float64
+	for i := range result.Fits {
+		result.Fits[i].AkaikeWeight = math.Exp(-(result.Fits[i].AICc - result.Fits[0].AICc) / 2)
+		total += result.Fits[i].AkaikeWeight
+	}
+	for i := range result.Fits {
+		result.Fits[i].AkaikeWeight /= total
+	}
+
+	result.Best = result.Fits[0].Model
+	return result, nil
+}
+
+// weightedLine fits y = a + b·x with weights 1/y² and returns the
+// parameters with the weighted residual and total sums of squares
+func weightedLine(x, y []float64) (a, b, rss, tss float64) {
+	var sw, swx, swy, swxx, swxy float64
+	for i := range x {
+		w := 1 / (y[i] * y[i])
+		sw += w
+		swx += w * x[i]
+		swy += w * y[i]
+		swxx += w * x[i] * x[i]
+		swxy += w * x[i] * y[i]
+	}
+
+	det := sw*swxx - swx*swx
+	if det == 0 {
+		a = swy / sw
+	} else {
+		b = (sw*swxy - swx*swy) / det
+		a = (swy - b*swx) / sw
+	}
+
+	mean := swy / sw
+	for i := range x {
+		w := 1 / (y[i] * y[i])
+		r := y[i] - a - b*x[i]
+		rss += w * r * r
+		d := y[i] - mean
+		tss += w * d * d
+	}
+	return a, b, rss, tss
+}
+
+var claimPattern = regexp.MustCompile(`O\(([^)]*)\)`)
+
+// Claims extracts the complexity classes named in a free-text claim such as
+// "O(n log n) avg, O(n²) worst", normalized to the model names
+func Claims(text string) []string {
+	var claims []string
+	for _, match := range claimPattern.FindAllStringSubmatch(text, -1) {
+		inner := strings.NewReplacer(" ", "", "*", "", "·", "", "(", "", "^2", "²", "lg", "log").Replace(strings.ToLower(match[1]))
+		switch inner {
+		case "n":
+			claims = append(claims, Linear)
+		case "nlogn", "lognn":
+			claims = append(claims, Linearithmic)
+		case "n²", "nn":
+			claims = append(claims, Quadratic)
+		case "kn", "nk", "dn", "nd":
+			claims = append(claims, DigitLinear)
+		}
+	}
+	return claims
+}
+
+// Consistent reports whether the best-fitting model agrees with any of the
+// claimed ones. O(kn) with a fixed key width is linear, so O(n) and O(kn)
+// count as agreeing.
+func Consistent(best string, claims []string) bool {
+	for _, claim := range claims {
+		if claim == best {
+			return true
+		}
+		if (claim == DigitLinear && best == Linear) || (claim == Linear && best == DigitLinear) {
+			return true
+		}
+	}
+	return false
+}
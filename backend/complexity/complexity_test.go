@@ -0,0 +1,149 @@
+package complexity
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// synthetic returns runtimes t = overhead + scale·g(n) at sizes from 2^8 to
+// 2^20, with 2% multiplicative noise from a fixed seed
+func synthetic(g func(n float64) float64, scale float64) (sizes, runtimes []float64) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for p := 8.0; p <= 20; p += 0.5 {
+		n := math.Round(math.Pow(2, p))
+		sizes = append(sizes, n)
+		runtimes = append(runtimes, (1e-4+scale*g(n))*(1+0.02*rng.NormFloat64()))
+	}
+	return sizes, runtimes
+}
+
+func TestAnalyzeRanksGrowthLaws(t *testing.T) {
+	tests := []struct {
+		name  string
+		g     func(n float64) float64
+		scale float64
+		want  string
+	}{
+		{"linear", func(n float64) float64 { return n }, 1e-8, Linear},
+		{"n log n", func(n float64) float64 { return n * math.Log2(n) }, 1e-9, Linearithmic},
+		{"n log n with another base", func(n float64) float64 { return n * math.Log(n) }, 1e-9, Linearithmic},
+		{"quadratic", func(n float64) float64 { return n * n }, 1e-12, Quadratic},
+		{"radix digits", func(n float64) float64 { return math.Ceil(math.Log10(n)) * n }, 1e-8, DigitLinear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes, runtimes := synthetic(tt.g, tt.scale)
+			result, err := Analyze(sizes, runtimes, Options{})
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if result.Best != tt.want {
+				t.Fatalf("best = %s, want %s; fits %+v", result.Best, tt.want, result.Fits)
+			}
+			if result.Points != len(sizes) || len(result.Fits) != 4 {
+				t.Errorf("points, fits = %d, %d", result.Points, len(result.Fits))
+			}
+
+			var total float64
+			for i, fit := range result.Fits {
+				total += fit.AkaikeWeight
+				if i > 0 && fit.AICc < result.Fits[i-1].AICc {
+					t.Errorf("fits are not ordered by AICc: %+v", result.Fits)
+				}
+			}
+			if math.Abs(total-1) > 1e-9 {
+				t.Errorf("Akaike weights sum to %g", total)
+			}
+			if best := result.Fits[0]; best.AkaikeWeight < 0.9 || best.RSquared < 0.99 {
+				t.Errorf("best fit has weight %.3f and R² %.4f", best.AkaikeWeight, best.RSquared)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRecoversCoefficients(t *testing.T) {
+	sizes := []float64{16, 256, 4096, 65536, 1048576}
+	runtimes := make([]float64, len(sizes))
+	for i, n := range sizes {
+		runtimes[i] = 0.5 + 2e-6*n*math.Log2(n)
+	}
+
+	result, err := Analyze(sizes, runtimes, Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	best := result.Fits[0]
+	if best.Model != Linearithmic || math.Abs(best.Intercept-0.5) > 1e-9 || math.Abs(best.Coefficient-2e-6) > 1e-15 {
+		t.Errorf("best fit = %+v, want O(n log n) with intercept 0.5 and coefficient 2e-6", best)
+	}
+}
+
+func TestAnalyzeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    []float64
+		runtimes []float64
+		want     string
+	}{
+		{"length mismatch", []float64{1, 2, 3, 4}, []float64{1, 2, 3}, "input sizes for"},
+		{"too few points", []float64{1, 2, 3}, []float64{1, 2, 3}, "at least 4 points"},
+		{"size below one", []float64{0, 2, 3, 4}, []float64{1, 2, 3, 4}, "at least 1"},
+		{"NaN size", []float64{math.NaN(), 2, 3, 4}, []float64{1, 2, 3, 4}, "at least 1"},
+		{"zero runtime", []float64{1, 2, 3, 4}, []float64{1, 0, 3, 4}, "positive"},
+		{"infinite runtime", []float64{1, 2, 3, 4}, []float64{1, 2, math.Inf(1), 4}, "positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Analyze(tt.sizes, tt.runtimes, Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaims(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"O(n log n) avg, O(n²) worst", []string{Linearithmic, Quadratic}},
+		{"O(n lg n)", []string{Linearithmic}},
+		{"O(n^2)", []string{Quadratic}},
+		{"O(N)", []string{Linear}},
+		{"O(n·k)", []string{DigitLinear}},
+		{"O(log n)", nil},
+		{"fast", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Claims(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Claims(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestConsistent(t *testing.T) {
+	tests := []struct {
+		best   string
+		claims []string
+		want   bool
+	}{
+		{Linearithmic, []string{Linearithmic, Quadratic}, true},
+		{Quadratic, []string{Linearithmic}, false},
+		{Linear, []string{DigitLinear}, true},
+		{DigitLinear, []string{Linear}, true},
+		{Linear, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := Consistent(tt.best, tt.claims); got != tt.want {
+			t.Errorf("Consistent(%s, %v) = %t, want %t", tt.best, tt.claims, got, tt.want)
+		}
+	}
+}
@@ -1,50 +1,118 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"fresherpaint/backend/jwtkeys"
 )
 
 type Config struct {
-	ServerPort   string
-	DBHost       string
-	DBPort       string
-	DBUser       string
-	DBPassword   string
-	DBName       string
-	JWTSecret    string
-	SitePassword string
+	ServerPort string
+	DBHost     string
+	DBPort     string
+	DBUser     string
+	DBPassword string
+	DBName     string
+	JWTSecret  string
+	SeedMode   string
+	DataSeed   int64 // seed the built-in datasets are generated from
+	// AdminUsername and AdminPassword create the first admin account when
+	// no users exist; SITE_PASSWORD is still read for older deployments
+	AdminUsername string
+	AdminPassword string
+	// Lifetimes of access tokens and of refresh tokens
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// JWTAlgorithm signs access tokens (HS256, RS256 or EdDSA). Keys are
+	// replaced every JWTKeyRotation and verify for JWTKeyOverlap after that.
+	JWTAlgorithm   string
+	JWTKeyRotation time.Duration
+	JWTKeyOverlap  time.Duration
+	// Token bucket rates such as "300/m" (see ratelimit.ParseRate) for
+	// protected routes, for expensive routes and per IP for login/refresh
+	RateLimitAPI   string
+	RateLimitHeavy string
+	RateLimitAuth  string
+	// TrustProxy takes client IPs from X-Forwarded-For
+	TrustProxy bool
+	// CORS: browser origins allowed to call the API, as exact origins or
+	// https://*.example.com patterns
+	CORSAllowedOrigins   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+	// LogLevel is debug, info, warn or error and LogFormat text or json.
+	// HealthLogSample logs one in every N successful health checks; 0 logs
+	// none of them.
+	LogLevel        string
+	LogFormat       string
+	HealthLogSample int64
+	// MetricsToken, if set, is the bearer token Prometheus must send to
+	// read GET /metrics
+	MetricsToken string
 }
 
 func LoadConfig() *Config {
 	// Load environment variables from .env file
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found or could not be loaded: %v", err)
+		slog.Warn(".env file not found or could not be loaded", "error", err)
 	}
 
-	// Check for Render's DATABASE_URL first
+	// Check for Render's DATABASE_URL first, else use the local development
+	// database settings
+	var config *Config
 	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
-		return parsePostgresURL(databaseURL)
+		config = parsePostgresURL(databaseURL)
+	} else {
+		config = &Config{
+			DBHost:     getEnv("DB_HOST", "localhost"),
+			DBPort:     getEnv("DB_PORT", "5432"),
+			DBUser:     getEnv("DB_USER", "fresherpaint"),
+			DBPassword: getEnv("DB_PASSWORD", "password"),
+			DBName:     getEnv("DB_NAME", "fresherpaint"),
+		}
 	}
 
-	// Set default values for local development
-	config := &Config{
-		ServerPort:   getEnv("PORT", getEnv("SERVER_PORT", "8080")), // Render uses PORT
-		DBHost:       getEnv("DB_HOST", "localhost"),
-		DBPort:       getEnv("DB_PORT", "5432"),
-		DBUser:       getEnv("DB_USER", "fresherpaint"),
-		DBPassword:   getEnv("DB_PASSWORD", "password"),
-		DBName:       getEnv("DB_NAME", "fresherpaint"),
-		JWTSecret:    getEnv("JWT_SECRET", ""),
-		SitePassword: getEnv("SITE_PASSWORD", "source.tide.white"),
-	}
+	applyEnv(config)
 	return config
 }
 
+// applyEnv reads every setting other than the database connection
+func applyEnv(config *Config) {
+	config.ServerPort = getEnv("PORT", getEnv("SERVER_PORT", "8080")) // Render uses PORT
+	config.JWTSecret = getEnv("JWT_SECRET", "")
+	config.SeedMode = getEnv("SEED_MODE", SeedModeMissing)
+	config.DataSeed = getEnvInt64("DATA_SEED", DefaultDataSeed)
+	config.AdminUsername = getEnv("ADMIN_USERNAME", "admin")
+	config.AdminPassword = getEnv("ADMIN_PASSWORD", getEnv("SITE_PASSWORD", ""))
+	config.AccessTokenTTL = getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	config.RefreshTokenTTL = getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	config.JWTAlgorithm = getEnv("JWT_ALGORITHM", defaultJWTAlgorithm())
+	config.JWTKeyRotation = getEnvDuration("JWT_KEY_ROTATION", 30*24*time.Hour)
+	config.JWTKeyOverlap = getEnvDuration("JWT_KEY_OVERLAP", 24*time.Hour)
+	config.RateLimitAPI = getEnv("RATE_LIMIT_API", "300/m")
+	config.RateLimitHeavy = getEnv("RATE_LIMIT_HEAVY", "10/m")
+	config.RateLimitAuth = getEnv("RATE_LIMIT_AUTH", "30/m")
+	config.TrustProxy = getEnv("TRUST_PROXY", "false") == "true"
+	config.CORSAllowedOrigins = getEnvList("CORS_ALLOWED_ORIGINS", "http://localhost:3000")
+	config.CORSAllowedHeaders = getEnvList("CORS_ALLOWED_HEADERS", "Content-Type, Authorization")
+	config.CORSExposedHeaders = getEnvList("CORS_EXPOSED_HEADERS", "Retry-After, Location")
+	config.CORSAllowCredentials = getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true"
+	config.CORSMaxAge = getEnvDuration("CORS_MAX_AGE", 10*time.Minute)
+	config.LogLevel = getEnv("LOG_LEVEL", "info")
+	config.LogFormat = getEnv("LOG_FORMAT", "text")
+	config.HealthLogSample = getEnvInt64("LOG_HEALTH_SAMPLE", 0)
+	config.MetricsToken = getEnv("METRICS_TOKEN", "")
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -52,13 +120,60 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// parsePostgresURL parses a PostgreSQL URL and returns a Config
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		slog.Warn("Ignoring invalid integer setting", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// defaultJWTAlgorithm keeps deployments that set JWT_SECRET on HS256 with
+// that secret; otherwise tokens are signed with rotating RSA keys
+func defaultJWTAlgorithm() string {
+	if getEnv("JWT_SECRET", "") != "" {
+		return jwtkeys.HS256
+	}
+	return jwtkeys.RS256
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries
+func getEnvList(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvDuration reads a positive duration such as "15m" or "720h"
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		slog.Warn("Ignoring invalid duration setting", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// parsePostgresURL returns a Config holding the database connection settings
+// of a PostgreSQL URL, falling back to the local defaults when it does not parse
 func parsePostgresURL(databaseURL string) *Config {
 	u, err := url.Parse(databaseURL)
 	if err != nil {
-		log.Printf("Error parsing DATABASE_URL: %v", err)
+		slog.Error("Failed to parse DATABASE_URL", "error", err)
 		return &Config{
-			ServerPort: getEnv("PORT", "8080"),
 			DBHost:     "localhost",
 			DBPort:     "5432",
 			DBUser:     "fresherpaint",
@@ -68,21 +183,16 @@ func parsePostgresURL(databaseURL string) *Config {
 	}
 
 	password, _ := u.User.Password()
-	host := u.Hostname()
 	port := u.Port()
 	if port == "" {
 		port = "5432"
 	}
-	dbname := strings.TrimPrefix(u.Path, "/")
 
 	return &Config{
-		ServerPort:   getEnv("PORT", "8080"),
-		DBHost:       host,
-		DBPort:       port,
-		DBUser:       u.User.Username(),
-		DBPassword:   password,
-		DBName:       dbname,
-		JWTSecret:    getEnv("JWT_SECRET", ""),
-		SitePassword: getEnv("SITE_PASSWORD", "source.tide.white"),
+		DBHost:     u.Hostname(),
+		DBPort:     port,
+		DBUser:     u.User.Username(),
+		DBPassword: password,
+		DBName:     strings.TrimPrefix(u.Path, "/"),
 	}
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fresherpaint/backend/router"
+)
+
+// CORSPolicy decides which browser origins may call the API
+type CORSPolicy struct {
+	// exact holds allowed origins as scheme://host[:port]; anyOrigin is set
+	// by "*"
+	exact     map[string]bool
+	wildcards []originPattern
+	anyOrigin bool
+
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// originPattern matches every subdomain of host, e.g. https://*.example.com
+type originPattern struct {
+	scheme string
+	suffix string // ".example.com"
+	port   string
+}
+
+var corsPolicy *CORSPolicy
+
+// InitializeCORS builds the CORS policy from the configuration
+func InitializeCORS(config *Config) error {
+	policy := &CORSPolicy{
+		exact:            map[string]bool{},
+		AllowedHeaders:   config.CORSAllowedHeaders,
+		ExposedHeaders:   config.CORSExposedHeaders,
+		AllowCredentials: config.CORSAllowCredentials,
+		MaxAge:           config.CORSMaxAge,
+	}
+
+	for _, origin := range config.CORSAllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		switch {
+		case origin == "":
+			continue
+		case origin == "*":
+			policy.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			pattern, err := parseOriginPattern(origin)
+			if err != nil {
+				return err
+			}
+			policy.wildcards = append(policy.wildcards, pattern)
+		default:
+			if _, err := parseOrigin(origin); err != nil {
+				return err
+			}
+			policy.exact[origin] = true
+		}
+	}
+
+	// Browsers refuse credentials with a wildcard origin, and echoing any
+	// origin back with credentials would let every site act as the user
+	if policy.anyOrigin && policy.AllowCredentials {
+		return fmt.Errorf("CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS")
+	}
+
+	corsPolicy = policy
+	return nil
+}
+
+// parseOrigin checks that s is a bare scheme://host[:port] origin
+func parseOrigin(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return nil, fmt.Errorf("invalid CORS origin %q (expected scheme://host[:port])", s)
+	}
+	return u, nil
+}
+
+// parseOriginPattern parses scheme://*.domain[:port]
+func parseOriginPattern(s string) (originPattern, error) {
+	scheme, rest, ok := strings.Cut(s, "://*.")
+	if !ok || strings.Contains(rest, "*") {
+		return originPattern{}, fmt.Errorf("invalid CORS origin pattern %q (expected scheme://*.domain)", s)
+	}
+	u, err := parseOrigin(scheme + "://" + rest)
+	if err != nil {
+		return originPattern{}, err
+	}
+	return originPattern{scheme: u.Scheme, suffix: "." + u.Hostname(), port: u.Port()}, nil
+}
+
+// Allows reports whether a request Origin header value is allowed
+func (p *CORSPolicy) Allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.exact[origin] {
+		return true
+	}
+	if len(p.wildcards) == 0 {
+		return false
+	}
+
+	u, err := parseOrigin(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	for _, pattern := range p.wildcards {
+		if u.Scheme == pattern.scheme && u.Port() == pattern.port &&
+			len(host) > len(pattern.suffix) && strings.HasSuffix(host, pattern.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether every header named in an
+// Access-Control-Request-Headers value is allowed
+func (p *CORSPolicy) allowsHeaders(requested string) bool {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		allowed := false
+		for _, header := range p.AllowedHeaders {
+			allowed = allowed || strings.EqualFold(name, header)
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// corsMiddleware applies the CORS policy to a route, describing the methods
+// the router serves on its path. Requests without an Origin header (curl,
+// other servers) pass through; requests from origins outside the allowlist
+// are refused with 403. Preflight requests are answered here and never reach
+// the handler.
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The response depends on the Origin header, so caches must key on it
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !corsPolicy.Allows(origin) {
+			writeError(w, apiError(CodeOriginDenied, "Origin not allowed"))
+			return
+		}
+
+		if corsPolicy.anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if corsPolicy.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			methods := router.AllowedMethods(r)
+			allowedMethods := strings.Join(methods, ", ")
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			requested := r.Header.Get("Access-Control-Request-Method")
+			if !containsMethod(methods, requested) {
+				w.Header().Set("Allow", allowedMethods+", OPTIONS")
+				writeError(w, apiError(CodeMethodNotAllowed, "Method %s not allowed", requested))
+				return
+			}
+			if !corsPolicy.allowsHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+				writeError(w, apiError(CodeForbidden, "Request headers not allowed"))
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsPolicy.AllowedHeaders, ", "))
+			if corsPolicy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(corsPolicy.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(corsPolicy.ExposedHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsPolicy.ExposedHeaders, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fresherpaint/backend/router"
+)
+
+func testCORSPolicy(t *testing.T, origins ...string) *CORSPolicy {
+	t.Helper()
+	err := InitializeCORS(&Config{
+		CORSAllowedOrigins: origins,
+		CORSAllowedHeaders: []string{"Authorization", "Content-Type"},
+		CORSExposedHeaders: []string{"X-Request-ID"},
+		CORSMaxAge:         10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("InitializeCORS: %v", err)
+	}
+	return corsPolicy
+}
+
+func TestCORSAllows(t *testing.T) {
+	policy := testCORSPolicy(t, "https://app.example.org", "https://*.example.com", "http://*.local.test:8080", "http://localhost:3000/")
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.org", true},
+		{"HTTPS://APP.EXAMPLE.ORG", true},
+		{"https://app.example.org:443", false},
+		{"http://app.example.org", false},
+		{"https://other.example.org", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"http://localhost", false},
+
+		{"https://a.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://A.Example.COM", true},
+		{"https://example.com", false},
+		{"https://.example.com", false},
+		{"https://evilexample.com", false},
+		{"https://example.com.evil.net", false},
+		{"https://a.example.com.evil.net", false},
+		{"http://a.example.com", false},
+		{"https://a.example.com:8443", false},
+		{"https://a.example.com/path", false},
+		{"https://user@a.example.com", false},
+
+		{"http://x.local.test:8080", true},
+		{"http://x.local.test", false},
+		{"http://x.local.test:8081", false},
+
+		{"null", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Allows(tt.origin); got != tt.want {
+			t.Errorf("Allows(%q) = %t, want %t", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestInitializeCORSRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		want        string
+	}{
+		{"any origin with credentials", []string{"*"}, true, "cannot be combined"},
+		{"path in origin", []string{"https://example.com/app"}, false, "invalid CORS origin"},
+		{"no scheme", []string{"example.com"}, false, "invalid CORS origin"},
+		{"other scheme", []string{"ftp://example.com"}, false, "invalid CORS origin"},
+		{"wildcard inside a label", []string{"https://app-*.example.com"}, false, "invalid CORS origin pattern"},
+		{"two wildcards", []string{"https://*.*.example.com"}, false, "invalid CORS origin pattern"},
+		{"bare wildcard host", []string{"https://*"}, false, "invalid CORS origin pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := InitializeCORS(&Config{CORSAllowedOrigins: tt.origins, CORSAllowCredentials: tt.credentials})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if err := InitializeCORS(&Config{CORSAllowedOrigins: []string{"*"}}); err != nil || !corsPolicy.Allows("https://anything.test") {
+		t.Errorf("* without credentials: error %v, want every origin allowed", err)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	testCORSPolicy(t, "https://app.example.com")
+	rt := router.New()
+	rt.Use(corsMiddleware)
+	rt.HandleFunc("GET /api/items", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	rt.HandleFunc("POST /api/items", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+		// headers expected on the response; "" means absent
+		want map[string]string
+		vary []string
+	}{
+		{
+			name:   "no Origin passes through",
+			method: "GET",
+			status: 200,
+			want:   map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:   []string{"Origin"},
+		},
+		{
+			name:    "allowed origin",
+			method:  "GET",
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			status:  200,
+			want:    map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Expose-Headers": "X-Request-ID"},
+			vary:    []string{"Origin"},
+		},
+		{
+			name:    "denied origin",
+			method:  "GET",
+			headers: map[string]string{"Origin": "https://evil.example"},
+			status:  403,
+			want:    map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:    []string{"Origin"},
+		},
+		{
+			name:    "denied suffix spoof",
+			method:  "POST",
+			headers: map[string]string{"Origin": "https://evilapp.example.com"},
+			status:  403,
+			want:    map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:    []string{"Origin"},
+		},
+		{
+			name:   "preflight",
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type, Authorization",
+			},
+			status: 204,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Authorization, Content-Type",
+				"Access-Control-Max-Age":       "600",
+			},
+			vary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "preflight for a method the path does not serve",
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: 405,
+			want:   map[string]string{"Allow": "GET, POST, OPTIONS", "Access-Control-Allow-Methods": ""},
+			vary:   []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "preflight with a header outside the allowlist",
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "Authorization, X-Custom",
+			},
+			status: 403,
+			want:   map[string]string{"Access-Control-Allow-Headers": "", "Access-Control-Allow-Methods": ""},
+			vary:   []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:    "preflight from a denied origin",
+			method:  "OPTIONS",
+			headers: map[string]string{"Origin": "https://evil.example", "Access-Control-Request-Method": "GET"},
+			status:  403,
+			want:    map[string]string{"Access-Control-Allow-Methods": ""},
+			vary:    []string{"Origin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/items", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			for name, want := range tt.want {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := rec.Header().Values("Vary"); strings.Join(got, ",") != strings.Join(tt.vary, ",") {
+				t.Errorf("Vary = %v, want %v", got, tt.vary)
+			}
+		})
+	}
+}
+
+func TestCORSMiddlewareCredentials(t *testing.T) {
+	if err := InitializeCORS(&Config{CORSAllowedOrigins: []string{"https://app.example.com"}, CORSAllowCredentials: true}); err != nil {
+		t.Fatalf("InitializeCORS: %v", err)
+	}
+	handler := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("headers = %v, want the origin echoed with credentials allowed", rec.Header())
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/schema"
+)
+
+const (
+	// datasetTypeTTL is how long the cached table is trusted, so types
+	// changed or deleted by another replica are dropped within it
+	datasetTypeTTL = time.Minute
+	// datasetTypeMissTTL is how long a lookup that misses is answered from
+	// the cache before it may reload the table again, so requests naming
+	// unknown types cannot reload it on every request
+	datasetTypeMissTTL = 5 * time.Second
+)
+
+// DatasetTypeRegistry caches the dataset_types table. The table is reloaded
+// when the cache is older than datasetTypeTTL, and on a miss at most once
+// per datasetTypeMissTTL, so types added by another replica are picked up.
+type DatasetTypeRegistry struct {
+	mu       sync.RWMutex
+	types    map[string]models.DatasetType
+	schemas  map[string]*schema.Schema // compiled data_schema per type, if set
+	loadedAt time.Time
+
+	// loading serialises reloads so concurrent misses share one query
+	loading sync.Mutex
+}
+
+var datasetTypes = &DatasetTypeRegistry{
+	types:   map[string]models.DatasetType{},
+	schemas: map[string]*schema.Schema{},
+}
+
+// Load replaces the cached types with the contents of dataset_types
+func (r *DatasetTypeRegistry) Load() error {
+	rows, err := database.GetDB().Query(
+		"SELECT name, display_name, description, data_schema, created_at FROM dataset_types",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset types: %w", err)
+	}
+	defer rows.Close()
+
+	types := map[string]models.DatasetType{}
+	schemas := map[string]*schema.Schema{}
+	for rows.Next() {
+		var t models.DatasetType
+		var doc []byte
+		if err := rows.Scan(&t.Name, &t.DisplayName, &t.Description, &doc, &t.CreatedAt); err != nil {
+			return err
+		}
+		t.DataSchema = doc
+		types[t.Name] = t
+
+		if len(doc) > 0 {
+			compiled, err := schema.Compile(doc)
+			if err != nil {
+				return fmt.Errorf("invalid data_schema for dataset type %q: %w", t.Name, err)
+			}
+			schemas[t.Name] = compiled
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.types = types
+	r.schemas = schemas
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// Get returns the named type, reloading the registry when the cached answer
+// is too old to trust. If a reload fails the cached answer is used.
+func (r *DatasetTypeRegistry) Get(name string) (models.DatasetType, bool) {
+	t, ok, fresh := r.cached(name)
+	if fresh {
+		return t, ok
+	}
+
+	r.loading.Lock()
+	defer r.loading.Unlock()
+	// Another request may have reloaded while this one waited
+	if t, ok, fresh = r.cached(name); fresh {
+		return t, ok
+	}
+	if err := r.Load(); err != nil {
+		slog.Warn("Failed to reload dataset types", "error", err)
+		return t, ok
+	}
+
+	t, ok, _ = r.cached(name)
+	return t, ok
+}
+
+// Refresh reloads the table if the cache is older than datasetTypeTTL
+func (r *DatasetTypeRegistry) Refresh() error {
+	if r.fresh() {
+		return nil
+	}
+
+	r.loading.Lock()
+	defer r.loading.Unlock()
+	if r.fresh() {
+		return nil
+	}
+	return r.Load()
+}
+
+// fresh reports whether the whole cache is recent enough to serve
+func (r *DatasetTypeRegistry) fresh() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return time.Since(r.loadedAt) < datasetTypeTTL
+}
+
+// cached looks a type up in the cache and reports whether the answer is
+// recent enough to use without reloading
+func (r *DatasetTypeRegistry) cached(name string) (models.DatasetType, bool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[name]
+	ttl := datasetTypeTTL
+	if !ok {
+		ttl = datasetTypeMissTTL
+	}
+	return t, ok, time.Since(r.loadedAt) < ttl
+}
+
+// Invalidate makes the next lookup reload the table, after the database
+// rejected a type that the cache still holds
+func (r *DatasetTypeRegistry) Invalidate() {
+	r.mu.Lock()
+	r.loadedAt = time.Time{}
+	r.mu.Unlock()
+}
+
+// Has reports whether the type is registered; it satisfies models.TypeChecker
+func (r *DatasetTypeRegistry) Has(name models.AnalyticsType) bool {
+	_, ok := r.Get(string(name))
+	return ok
+}
+
+// Schema returns the compiled data_schema of a type, or nil if it has none
+func (r *DatasetTypeRegistry) Schema(name string) *schema.Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemas[name]
+}
+
+// List returns every registered type ordered by name
+func (r *DatasetTypeRegistry) List() []models.DatasetType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]models.DatasetType, 0, len(r.types))
+	for _, t := range r.types {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Create registers a new type
+func (r *DatasetTypeRegistry) Create(in *models.DatasetTypeInput) (*models.DatasetType, error) {
+	row := database.GetDB().QueryRow(`
+		INSERT INTO dataset_types (name, display_name, description, data_schema)
+		VALUES ($1, $2, $3, $4)
+		RETURNING name, display_name, description, data_schema, created_at`,
+		in.Name, in.DisplayName, in.Description, nullableJSON(in.DataSchema),
+	)
+	return r.store(row)
+}
+
+// Update replaces the display name, description and schema of a type
+func (r *DatasetTypeRegistry) Update(in *models.DatasetTypeInput) (*models.DatasetType, error) {
+	row := database.GetDB().QueryRow(`
+		UPDATE dataset_types SET display_name = $2, description = $3, data_schema = $4
+		WHERE name = $1
+		RETURNING name, display_name, description, data_schema, created_at`,
+		in.Name, in.DisplayName, in.Description, nullableJSON(in.DataSchema),
+	)
+	return r.store(row)
+}
+
+// Delete removes a type; it fails while datasets still use it
+func (r *DatasetTypeRegistry) Delete(name string) (bool, error) {
+	result, err := database.GetDB().Exec("DELETE FROM dataset_types WHERE name = $1", name)
+	if err != nil {
+		return false, err
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		return false, nil
+	}
+
+	r.mu.Lock()
+	delete(r.types, name)
+	delete(r.schemas, name)
+	r.mu.Unlock()
+	return true, nil
+}
+
+// store scans a returned dataset_types row and caches it
+func (r *DatasetTypeRegistry) store(row *sql.Row) (*models.DatasetType, error) {
+	var t models.DatasetType
+	var doc []byte
+	if err := row.Scan(&t.Name, &t.DisplayName, &t.Description, &doc, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	t.DataSchema = doc
+
+	var compiled *schema.Schema
+	if len(doc) > 0 {
+		var err error
+		if compiled, err = schema.Compile(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	r.types[t.Name] = t
+	if compiled != nil {
+		r.schemas[t.Name] = compiled
+	} else {
+		delete(r.schemas, t.Name)
+	}
+	r.mu.Unlock()
+	return &t, nil
+}
+
+// nullableJSON maps an absent JSON document to SQL NULL
+func nullableJSON(doc []byte) interface{} {
+	if len(doc) == 0 {
+		return nil
+	}
+	return doc
+}
+
+// isPQError reports whether err is a PostgreSQL error with the given SQLSTATE code
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// datasetStoreError reports a failed insert or update of a dataset. A type
+// deleted after the request was validated violates the foreign key, and is
+// reported like any other unregistered type.
+func datasetStoreError(err error, dataType *models.AnalyticsType, failure string) error {
+	if !isPQError(err, "23503") {
+		return internalError(failure, err)
+	}
+
+	datasetTypes.Invalidate()
+	message := "data_type is not a registered dataset type"
+	if dataType != nil {
+		message = fmt.Sprintf("data_type %q is not a registered dataset type", *dataType)
+	}
+	return models.ValidationErrors{{Field: "data_type", Message: message}}
+}
+
+// validateDatasetTypeInput validates the fields and checks that data_schema compiles
+func validateDatasetTypeInput(input *models.DatasetTypeInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	if input.DataSchema != nil {
+		if _, err := schema.Compile(input.DataSchema); err != nil {
+			return models.ValidationErrors{{Field: "data_schema", Message: "data_schema is not a usable JSON Schema: " + err.Error()}}
+		}
+	}
+	return nil
+}
+
+// listDatasetTypesHandler serves GET /api/dataset-types
+func listDatasetTypesHandler(w http.ResponseWriter, r *http.Request) {
+	if err := datasetTypes.Refresh(); err != nil {
+		writeError(w, internalError("Failed to load dataset types", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: datasetTypes.List()})
+}
+
+// createDatasetTypeHandler serves POST /api/admin/dataset-types
+func createDatasetTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var input models.DatasetTypeInput
+	if err := decodeDatasetBody(w, r, &input); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+
+	if err := validateDatasetTypeInput(&input); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	t, err := datasetTypes.Create(&input)
+	if isPQError(err, "23505") {
+		writeError(w, apiError(CodeConflict, "Dataset type %q already exists", input.Name))
+		return
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to create dataset type", err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, APIResponse{Success: true, Data: t})
+}
+
+// updateDatasetTypeHandler serves PUT /api/admin/dataset-types/{name}
+func updateDatasetTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var input models.DatasetTypeInput
+	if err := decodeDatasetBody(w, r, &input); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+
+	input.Name = r.PathValue("name")
+	if err := validateDatasetTypeInput(&input); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	t, err := datasetTypes.Update(&input)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, notFound("Dataset type"))
+		return
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to update dataset type", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: t})
+}
+
+// deleteDatasetTypeHandler serves DELETE /api/admin/dataset-types/{name}
+func deleteDatasetTypeHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	found, err := datasetTypes.Delete(name)
+	if isPQError(err, "23503") {
+		writeError(w, apiError(CodeConflict, "Dataset type %q is still used by datasets", name))
+		return
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to delete dataset type", err))
+		return
+	}
+	if !found {
+		writeError(w, notFound("Dataset type"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]string{"name": name}})
+}
@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationLockKey is the pg_advisory_lock key that serialises migration runs
+// across replicas starting at the same time
+const migrationLockKey = 727364001
+
+// Migration files are named NNN_description.sql, with an optional
+// NNN_description.down.sql that reverts them
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+?)(\.down)?\.sql$`)
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version  int
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+}
+
+// MigrationStatus describes a migration relative to the database
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Drifted   bool       `json:"drifted"` // applied checksum differs from the file
+	Missing   bool       `json:"missing"` // applied but no file on disk
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations found in a directory and records them in
+// the schema_migrations table
+type Migrator struct {
+	db    *sql.DB
+	files fs.FS
+}
+
+// NewMigrator returns a migrator for the migration files in dir
+func NewMigrator(database *Database, dir string) *Migrator {
+	return &Migrator{db: database.db, files: os.DirFS(dir)}
+}
+
+// LoadMigrations reads every numbered migration file in the directory, ordered by version
+func (m *Migrator) LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	seen := map[string]string{} // version and direction -> file name
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		key := strconv.Itoa(version) + match[3]
+		if previous, ok := seen[key]; ok {
+			return nil, fmt.Errorf("migration files %s and %s have the same version", previous, entry.Name())
+		}
+		seen[key] = entry.Name()
+
+		contents, err := fs.ReadFile(m.files, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == ".down" {
+			migration.DownSQL = string(contents)
+		} else {
+			migration.UpSQL = string(contents)
+			sum := sha256.Sum256(contents)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("migration %03d_%s has a down file but no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction. It refuses to run when an applied migration has drifted.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(func(conn *sql.Conn) error {
+		migrations, done, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+
+		if err := verify(migrations, done); err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the most recently applied migrations, newest first
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+
+	var reverted []Migration
+	err := m.withLock(func(conn *sql.Conn) error {
+		migrations, done, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+
+		if err := verify(migrations, done); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if err := m.revert(conn, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status reports every known migration, plus applied versions whose file is gone
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(func(conn *sql.Conn) error {
+		migrations, done, err := m.load(conn)
+		if err != nil {
+			return err
+		}
+
+		known := map[int]bool{}
+		for _, migration := range migrations {
+			known[migration.Version] = true
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if row, ok := done[migration.Version]; ok {
+				appliedAt := row.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.Drifted = row.Checksum != migration.Checksum
+			}
+			statuses = append(statuses, status)
+		}
+
+		for version, row := range done {
+			if known[version] {
+				continue
+			}
+			appliedAt := row.AppliedAt
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				Name:      row.Name,
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, err
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	return fn(conn)
+}
+
+// load ensures schema_migrations exists and returns the files and applied rows
+func (m *Migrator) load(conn *sql.Conn) ([]Migration, map[int]appliedMigration, error) {
+	ctx := context.Background()
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	migrations, err := m.LoadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := map[int]appliedMigration{}
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.Version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, nil, err
+		}
+		done[row.Version] = row
+	}
+
+	return migrations, done, rows.Err()
+}
+
+// apply runs a migration's up SQL and records it in one transaction
+func (m *Migrator) apply(conn *sql.Conn, migration Migration) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.UpSQL); err != nil {
+		return fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		migration.Version, migration.Name, migration.Checksum,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// revert runs a migration's down SQL and removes its record in one transaction
+func (m *Migrator) revert(conn *sql.Conn, migration Migration) error {
+	if migration.DownSQL == "" {
+		return fmt.Errorf("migration %03d_%s has no down migration", migration.Version, migration.Name)
+	}
+
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.DownSQL); err != nil {
+		return fmt.Errorf("down migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return fmt.Errorf("failed to unrecord migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// verify fails when an applied migration was edited or deleted after it ran
+func verify(migrations []Migration, done map[int]appliedMigration) error {
+	known := map[int]bool{}
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		row, ok := done[migration.Version]
+		if ok && row.Checksum != migration.Checksum {
+			return fmt.Errorf("checksum mismatch for applied migration %03d_%s: the file was modified after it ran",
+				migration.Version, migration.Name)
+		}
+	}
+
+	for version, row := range done {
+		if !known[version] {
+			return fmt.Errorf("applied migration %03d_%s is missing from the migrations directory", version, row.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func file(contents string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(contents)}
+}
+
+func checksum(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoadMigrations(t *testing.T) {
+	m := &Migrator{files: fstest.MapFS{
+		"010_add_index.sql":     file("CREATE INDEX i ON t (c);"),
+		"002_users.sql":         file("CREATE TABLE users ();"),
+		"002_users.down.sql":    file("DROP TABLE users;"),
+		"001_init.sql":          file("CREATE TABLE t ();"),
+		"README.md":             file("not a migration"),
+		"003_init.SQL":          file("wrong extension case"),
+		"004-dashes.sql":        file("no underscore after the version"),
+		"005_up.up.sql":         file("dot in the name"),
+		"init.sql":              file("no version"),
+		"006_folder.sql/up.sql": file("a directory that matches the pattern"),
+	}}
+
+	migrations, err := m.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "init", UpSQL: "CREATE TABLE t ();"},
+		{Version: 2, Name: "users", UpSQL: "CREATE TABLE users ();", DownSQL: "DROP TABLE users;"},
+		{Version: 10, Name: "add_index", UpSQL: "CREATE INDEX i ON t (c);"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d: %+v", len(migrations), len(want), migrations)
+	}
+	for i, w := range want {
+		w.Checksum = checksum(w.UpSQL)
+		if migrations[i] != w {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], w)
+		}
+	}
+}
+
+func TestLoadMigrationsRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			name:  "one version, two up files",
+			files: fstest.MapFS{"001_init.sql": file("a"), "001_other.sql": file("b")},
+			want:  "001_init.sql and 001_other.sql have the same version",
+		},
+		{
+			name:  "down file named differently from its up file",
+			files: fstest.MapFS{"001_init.sql": file("a"), "001_other.down.sql": file("b")},
+			want:  `used by both "init" and "other"`,
+		},
+		{
+			name:  "one version written two ways",
+			files: fstest.MapFS{"1_init.sql": file("a"), "001_init.sql": file("b")},
+			want:  "have the same version",
+		},
+		{
+			name:  "two down files for one version",
+			files: fstest.MapFS{"001_init.sql": file("a"), "001_init.down.sql": file("b"), "01_init.down.sql": file("c")},
+			want:  "have the same version",
+		},
+		{
+			name:  "down file without an up file",
+			files: fstest.MapFS{"001_init.sql": file("a"), "002_users.down.sql": file("DROP TABLE users;")},
+			want:  "002_users has a down file but no up file",
+		},
+		{
+			name:  "version too large",
+			files: fstest.MapFS{"99999999999999999999_init.sql": file("a")},
+			want:  "invalid migration version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&Migrator{files: tt.files}).LoadMigrations()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init", Checksum: checksum("a")},
+		{Version: 2, Name: "users", Checksum: checksum("b")},
+	}
+
+	tests := []struct {
+		name string
+		done map[int]appliedMigration
+		want string // "" means no error
+	}{
+		{"nothing applied", map[int]appliedMigration{}, ""},
+		{"some applied", map[int]appliedMigration{1: {Version: 1, Name: "init", Checksum: checksum("a")}}, ""},
+		{
+			name: "applied file was edited",
+			done: map[int]appliedMigration{
+				1: {Version: 1, Name: "init", Checksum: checksum("a")},
+				2: {Version: 2, Name: "users", Checksum: checksum("b, before the edit")},
+			},
+			want: "checksum mismatch for applied migration 002_users",
+		},
+		{
+			name: "applied file was deleted",
+			done: map[int]appliedMigration{
+				1: {Version: 1, Name: "init", Checksum: checksum("a")},
+				3: {Version: 3, Name: "dropped", Checksum: checksum("c")},
+			},
+			want: "applied migration 003_dropped is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(migrations, tt.done)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
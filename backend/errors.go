@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"fresherpaint/backend/models"
+)
+
+// ErrorCode is a stable, machine-readable identifier for an error response.
+// Clients should branch on the code, not on the message.
+type ErrorCode string
+
+const (
+	CodeBadRequest       ErrorCode = "bad_request"
+	CodeInvalidBody      ErrorCode = "invalid_body"
+	CodeValidation       ErrorCode = "validation_failed"
+	CodeUnprocessable    ErrorCode = "unprocessable"
+	CodeUnauthorized     ErrorCode = "unauthorized"
+	CodeInvalidToken     ErrorCode = "invalid_token"
+	CodeForbidden        ErrorCode = "forbidden"
+	CodeOriginDenied     ErrorCode = "origin_not_allowed"
+	CodeNotFound         ErrorCode = "not_found"
+	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
+	CodeNotAcceptable    ErrorCode = "not_acceptable"
+	CodeConflict         ErrorCode = "conflict"
+	CodePayloadTooLarge  ErrorCode = "payload_too_large"
+	CodeUnsupportedMedia ErrorCode = "unsupported_media_type"
+	CodeRateLimited      ErrorCode = "rate_limited"
+	CodeInternal         ErrorCode = "internal_error"
+	CodeUnavailable      ErrorCode = "service_unavailable"
+)
+
+// errorStatus maps each code to its HTTP status
+var errorStatus = map[ErrorCode]int{
+	CodeBadRequest:       http.StatusBadRequest,
+	CodeInvalidBody:      http.StatusBadRequest,
+	CodeValidation:       http.StatusUnprocessableEntity,
+	CodeUnprocessable:    http.StatusUnprocessableEntity,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeInvalidToken:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeOriginDenied:     http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+	CodeNotAcceptable:    http.StatusNotAcceptable,
+	CodeConflict:         http.StatusConflict,
+	CodePayloadTooLarge:  http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMedia: http.StatusUnsupportedMediaType,
+	CodeRateLimited:      http.StatusTooManyRequests,
+	CodeInternal:         http.StatusInternalServerError,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+}
+
+// APIError is an error with a code and a message that are safe to show to
+// clients. Cause, if any, is logged but never sent.
+type APIError struct {
+	Code    ErrorCode
+	Message string
+	Details interface{}
+	Cause   error
+}
+
+func (e *APIError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Cause
+}
+
+// Status is the HTTP status for the error's code
+func (e *APIError) Status() int {
+	if status, ok := errorStatus[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// apiError returns an error with a client-safe message
+func apiError(code ErrorCode, format string, args ...interface{}) *APIError {
+	return &APIError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// internalError wraps an unexpected failure. The message describes what
+// failed, e.g. "Failed to create dataset"; the cause is only logged.
+func internalError(message string, cause error) *APIError {
+	return &APIError{Code: CodeInternal, Message: message, Cause: cause}
+}
+
+// notFound reports a missing resource, e.g. notFound("Dataset")
+func notFound(resource string) *APIError {
+	return apiError(CodeNotFound, "%s not found", resource)
+}
+
+// invalidBody reports a request body that could not be decoded
+func invalidBody(err error) *APIError {
+	return unreadable("Invalid request body", err)
+}
+
+// unreadable reports request input that could not be read, or a body that
+// went over its size limit
+func unreadable(message string, err error) *APIError {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apiError(CodePayloadTooLarge, "Request body must be at most %d bytes", tooLarge.Limit)
+	}
+	return &APIError{Code: CodeInvalidBody, Message: message + ": " + err.Error()}
+}
+
+// toAPIError converts any error to an APIError. Validation errors from the
+// models keep their field details; anything unrecognised is internal.
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var problems models.ValidationErrors
+	if errors.As(err, &problems) {
+		return &APIError{Code: CodeValidation, Message: problems.Error(), Details: problems}
+	}
+	return internalError("Internal server error", err)
+}
+
+// writeError writes err as a JSON error response with its code and the
+// request ID. Internal errors are logged with their cause, and clients only
+// see the safe message.
+func writeError(w http.ResponseWriter, err error) {
+	apiErr := toAPIError(err)
+	requestID := w.Header().Get(requestIDHeader)
+
+	status := apiErr.Status()
+	if status >= http.StatusInternalServerError {
+		slog.Error("Request failed", "request_id", requestID, "code", apiErr.Code, "error", apiErr)
+	}
+
+	writeJSON(w, status, APIResponse{
+		Success:   false,
+		Error:     apiErr.Message,
+		Code:      apiErr.Code,
+		Details:   apiErr.Details,
+		RequestID: requestID,
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fresherpaint/backend/tabular"
+)
+
+// exportAnalyticsDataHandler serves GET /api/analytics/{id}/export.
+//
+// One record array of the payload is flattened into a table (?table=, by
+// default the shape's main array) and streamed in the format given by
+// ?format= or negotiated from the Accept header, CSV when neither is set.
+func exportAnalyticsDataHandler(w http.ResponseWriter, r *http.Request, id string) {
+	format, ok := negotiateExportFormat(r)
+	if !ok {
+		names := make([]string, len(tabular.Formats))
+		for i, f := range tabular.Formats {
+			names[i] = f.Name
+		}
+		writeError(w, apiError(CodeNotAcceptable, "Unsupported export format (expected one of %s)", strings.Join(names, ", ")))
+		return
+	}
+
+	item, ok := loadDataset(w, id)
+	if !ok {
+		return
+	}
+
+	payload, _ := item.Data.(map[string]interface{})
+	tables := tabular.RecordArrays(payload)
+	if len(tables) == 0 {
+		writeError(w, apiError(CodeUnprocessable, "Dataset has no record arrays to export"))
+		return
+	}
+
+	name := r.URL.Query().Get("table")
+	if name == "" {
+		name = tables[0]
+	}
+
+	table, err := tabular.Flatten(payload, name)
+	if err != nil {
+		writeError(w, &APIError{
+			Code:    CodeUnprocessable,
+			Message: "Cannot export table: " + err.Error(),
+			Details: map[string][]string{"tables": tables},
+		})
+		return
+	}
+
+	filename := exportFileName(item.Title) + "-" + exportFileName(name) + format.Extension
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Vary", "Accept")
+
+	// The status line has been sent once the writer starts, so a failure
+	// here can only be logged and the response cut short
+	if err := format.Write(w, table); err != nil {
+		slog.Error("Dataset export failed", "request_id", w.Header().Get(requestIDHeader), "dataset_id", id, "format", format.Name, "error", err)
+	}
+}
+
+// negotiateExportFormat picks the export format from ?format= or, failing
+// that, the highest-weighted Accept entry that names a supported type
+func negotiateExportFormat(r *http.Request) (tabular.Format, bool) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return tabular.FormatByName(strings.ToLower(name))
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return tabular.Formats[0], true
+	}
+
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, entry := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{mediaType, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		switch c.mediaType {
+		case "*/*", "text/*":
+			return tabular.Formats[0], true
+		case "application/jsonl", "application/x-jsonlines":
+			return tabular.FormatByName("jsonl")
+		case "application/x-parquet":
+			return tabular.FormatByName("parquet")
+		}
+		for _, format := range tabular.Formats {
+			if contentType, _, _ := mime.ParseMediaType(format.ContentType); contentType == c.mediaType {
+				return format, true
+			}
+		}
+	}
+	return tabular.Format{}, false
+}
+
+// exportFileName reduces a title to a safe lower-case file name component
+func exportFileName(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == "" {
+		return "dataset"
+	}
+	if len(name) > 80 {
+		name = strings.TrimSuffix(name[:80], "-")
+	}
+	return name
+}
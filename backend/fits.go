@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"fresherpaint/backend/fitting"
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/stats"
+)
+
+// defaultFitPath is the series fitted when a request names none
+const defaultFitPath = "measurements.invariant_mass"
+
+// FitRequest is the body of POST /api/analytics/{id}/fits
+type FitRequest struct {
+	Path string `json:"path"`
+	fitting.Model
+}
+
+const datasetFitColumns = "id, dataset_id, path, result, dataset_updated_at, created_at"
+
+// createFitHandler fits a signal-plus-background model to a series of the
+// dataset and stores the result with it
+func createFitHandler(w http.ResponseWriter, r *http.Request, id string) {
+	var req FitRequest
+	if err := decodeDatasetBody(w, r, &req); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+	if req.Path == "" {
+		req.Path = defaultFitPath
+	}
+	if err := req.Model.Validate(); err != nil {
+		writeError(w, apiError(CodeValidation, "%v", err))
+		return
+	}
+
+	item, ok := loadDataset(w, id)
+	if !ok {
+		return
+	}
+
+	values, err := stats.Series(item.Data, req.Path)
+	if err != nil {
+		writeError(w, apiError(CodeUnprocessable, "%v", err))
+		return
+	}
+
+	result, err := fitting.Fit(values, req.Model)
+	if err != nil {
+		writeError(w, apiError(CodeUnprocessable, "Fit failed: %v", err))
+		return
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, internalError("Failed to encode fit result", err))
+		return
+	}
+
+	row := database.GetDB().QueryRow(`
+		INSERT INTO dataset_fits (dataset_id, path, result, dataset_updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+datasetFitColumns,
+		id, req.Path, encoded, item.UpdatedAt,
+	)
+	fit, err := scanDatasetFit(row)
+	if err != nil {
+		// The dataset may have been deleted while the fit ran
+		if isPQError(err, "23503") {
+			writeError(w, notFound("Dataset"))
+			return
+		}
+		writeError(w, internalError("Failed to store fit", err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, APIResponse{Success: true, Data: fit})
+}
+
+// listFitsHandler lists the stored fits of a dataset, newest first
+func listFitsHandler(w http.ResponseWriter, r *http.Request, id string) {
+	var exists bool
+	if err := database.GetDB().QueryRow("SELECT EXISTS (SELECT 1 FROM analytics_data WHERE id = $1)", id).Scan(&exists); err != nil {
+		writeError(w, internalError("Failed to fetch dataset", err))
+		return
+	}
+	if !exists {
+		writeError(w, notFound("Dataset"))
+		return
+	}
+
+	rows, err := database.GetDB().Query("SELECT "+datasetFitColumns+" FROM dataset_fits WHERE dataset_id = $1 ORDER BY created_at DESC", id)
+	if err != nil {
+		writeError(w, internalError("Failed to fetch fits", err))
+		return
+	}
+	defer rows.Close()
+
+	fits := []models.DatasetFit{}
+	for rows.Next() {
+		fit, err := scanDatasetFit(rows)
+		if err != nil {
+			writeError(w, internalError("Failed to fetch fits", err))
+			return
+		}
+		fits = append(fits, *fit)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, internalError("Failed to fetch fits", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: fits})
+}
+
+// scanDatasetFit scans one dataset_fits row selected with datasetFitColumns
+func scanDatasetFit(row rowScanner) (*models.DatasetFit, error) {
+	var fit models.DatasetFit
+	var result []byte
+	if err := row.Scan(&fit.ID, &fit.DatasetID, &fit.Path, &result, &fit.DatasetUpdatedAt, &fit.CreatedAt); err != nil {
+		return nil, err
+	}
+	fit.Result = result
+	return &fit, nil
+}
@@ -0,0 +1,462 @@
+// Package fitting fits signal-plus-background models to one-dimensional
+// data such as a diphoton invariant-mass spectrum. Fits maximize the
+// extended likelihood, unbinned for modest samples and binned above
+// MaxUnbinnedEvents, and report parameter uncertainties from the inverse
+// Hessian and the local significance of the signal from the likelihood ratio
+// against a background-only fit.
+package fitting
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"fresherpaint/backend/stats"
+)
+
+// Signal shapes
+const (
+	SignalGaussian    = "gaussian"
+	SignalCrystalBall = "crystal_ball"
+)
+
+// Background shapes
+const (
+	BackgroundPolynomial  = "polynomial"
+	BackgroundExponential = "exponential"
+)
+
+const (
+	// MaxPolynomialDegree is the highest background polynomial degree
+	MaxPolynomialDegree = 4
+
+	// MaxUnbinnedEvents is the largest sample fitted event by event; larger
+	// samples are fitted as a finely binned histogram
+	MaxUnbinnedEvents = 50000
+
+	// binnedFitBins is the number of bins used by binned fits
+	binnedFitBins = 500
+
+	minFitEvents = 10
+	maxEvals     = 20000
+)
+
+// Model describes what to fit. Range defaults to the span of the data and
+// Width, the starting signal width, to a fortieth of the range. Without a
+// starting Mass the fit starts from the largest local excess over the
+// background.
+type Model struct {
+	Signal     string      `json:"signal"`
+	Background string      `json:"background"`
+	Degree     int         `json:"degree,omitempty"`
+	Range      *[2]float64 `json:"range,omitempty"`
+	Mass       *float64    `json:"mass,omitempty"`
+	Width      *float64    `json:"width,omitempty"`
+}
+
+// Validate fills in default shapes and checks the model
+func (m *Model) Validate() error {
+	if m.Signal == "" {
+		m.Signal = SignalGaussian
+	}
+	if m.Background == "" {
+		m.Background = BackgroundPolynomial
+	}
+
+	switch m.Signal {
+	case SignalGaussian, SignalCrystalBall:
+	default:
+		return fmt.Errorf("signal must be %s or %s", SignalGaussian, SignalCrystalBall)
+	}
+
+	switch m.Background {
+	case BackgroundPolynomial:
+		if m.Degree < 0 || m.Degree > MaxPolynomialDegree {
+			return fmt.Errorf("degree must be between 0 and %d", MaxPolynomialDegree)
+		}
+	case BackgroundExponential:
+		if m.Degree != 0 {
+			return fmt.Errorf("degree only applies to the polynomial background")
+		}
+	default:
+		return fmt.Errorf("background must be %s or %s", BackgroundPolynomial, BackgroundExponential)
+	}
+
+	if m.Range != nil && !(m.Range[0] < m.Range[1]) {
+		return fmt.Errorf("range must be [low, high] with low < high")
+	}
+	if m.Width != nil && *m.Width <= 0 {
+		return fmt.Errorf("width must be positive")
+	}
+	return nil
+}
+
+// Estimate is a fitted value with its symmetric uncertainty. Error is zero
+// when the covariance matrix could not be computed.
+type Estimate struct {
+	Value float64 `json:"value"`
+	Error float64 `json:"error"`
+}
+
+// Parameter is a named fitted parameter
+type Parameter struct {
+	Name string `json:"name"`
+	Estimate
+}
+
+// Result is the outcome of a fit
+type Result struct {
+	Model           Model       `json:"model"`
+	Method          string      `json:"method"` // "unbinned" or "binned"
+	Events          int         `json:"events"` // values inside the range
+	Mass            Estimate    `json:"mass"`
+	Width           Estimate    `json:"width"`
+	SignalYield     Estimate    `json:"signal_yield"`
+	BackgroundYield Estimate    `json:"background_yield"`
+	Parameters      []Parameter `json:"parameters"`
+	Correlation     [][]float64 `json:"correlation,omitempty"`
+	NLL             float64     `json:"nll"`
+	BackgroundNLL   float64     `json:"background_only_nll"`
+	Significance    float64     `json:"local_significance"` // in standard deviations
+	PValue          float64     `json:"local_p_value"`
+	Converged       bool        `json:"converged"`
+	CovarianceOK    bool        `json:"covariance_ok"`
+	Evaluations     int         `json:"evaluations"`
+}
+
+// Fit fits the model to values; NaN and infinite values are ignored
+func Fit(values []float64, model Model) (*Result, error) {
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	data := stats.Finite(values)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no numeric values to fit")
+	}
+	sort.Float64s(data)
+
+	lo, hi := data[0], data[len(data)-1]
+	if model.Range != nil {
+		lo, hi = model.Range[0], model.Range[1]
+	} else {
+		model.Range = &[2]float64{lo, hi}
+	}
+	if !(lo < hi) {
+		return nil, fmt.Errorf("the values span no range to fit")
+	}
+	if model.Mass != nil && (*model.Mass < lo || *model.Mass > hi) {
+		return nil, fmt.Errorf("mass must be inside the range [%g, %g]", lo, hi)
+	}
+
+	first := sort.SearchFloat64s(data, lo)
+	last := sort.Search(len(data), func(i int) bool { return data[i] > hi })
+	data = data[first:last]
+	if len(data) < minFitEvents {
+		return nil, fmt.Errorf("at least %d values inside the range are needed, got %d", minFitEvents, len(data))
+	}
+
+	p := newProblem(data, lo, hi, model)
+	x0, step := p.start(model)
+
+	// Background-only fit for the likelihood ratio; it also seeds the
+	// search for the signal
+	background, nll0, evals0, _ := p.minimize(x0, step, true)
+	if model.Mass == nil {
+		x0 = p.scan(background, x0[3])
+	} else {
+		x0 = p.seed(background, *model.Mass, x0[3])
+	}
+
+	best, nll, evals, converged := p.minimize(x0, step, false)
+	result := &Result{
+		Model:         model,
+		Method:        p.method(),
+		Events:        len(data),
+		NLL:           nll,
+		BackgroundNLL: nll0,
+		Converged:     converged,
+		Evaluations:   evals0 + evals,
+	}
+
+	if q0 := 2 * (nll0 - nll); q0 > 0 && best[0] > 0 {
+		result.Significance = math.Sqrt(q0)
+	}
+	result.PValue = stats.NormalSurvival(result.Significance)
+
+	names := p.names()
+	errors := make([]float64, len(best))
+	h := make([]float64, len(best))
+	for i := range h {
+		h[i] = 1e-4 * math.Max(math.Abs(best[i]), step[i])
+	}
+	if cov, err := invert(hessian(p.nll, best, h)); err == nil {
+		result.CovarianceOK = true
+		result.Correlation = make([][]float64, len(best))
+		for i := range cov {
+			errors[i] = math.Sqrt(cov[i][i])
+		}
+		for i := range cov {
+			result.Correlation[i] = make([]float64, len(best))
+			for j := range cov {
+				result.Correlation[i][j] = cov[i][j] / (errors[i] * errors[j])
+			}
+		}
+	}
+
+	result.Parameters = make([]Parameter, len(best))
+	for i, name := range names {
+		result.Parameters[i] = Parameter{Name: name, Estimate: Estimate{Value: best[i], Error: errors[i]}}
+	}
+	result.SignalYield = result.Parameters[0].Estimate
+	result.BackgroundYield = result.Parameters[1].Estimate
+	result.Mass = result.Parameters[2].Estimate
+	result.Width = result.Parameters[3].Estimate
+
+	if !result.finite() {
+		return nil, fmt.Errorf("the fit did not reach a finite likelihood; try a narrower range or other starting values")
+	}
+	return result, nil
+}
+
+// finite reports whether every number in the result is finite, so that it
+// can be encoded as JSON and is meaningful to report
+func (r *Result) finite() bool {
+	values := []float64{r.NLL, r.BackgroundNLL, r.Significance, r.PValue}
+	for _, p := range r.Parameters {
+		values = append(values, p.Value, p.Error)
+	}
+	for _, row := range r.Correlation {
+		values = append(values, row...)
+	}
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// problem is the negative log-likelihood of one model and data set. The
+// parameter vector is [signal yield, background yield, signal shape…,
+// background shape…].
+type problem struct {
+	lo, hi     float64
+	events     []float64 // unbinned fits
+	centers    []float64 // binned fits
+	counts     []float64
+	binWidth   float64
+	signal     shape
+	background shape
+	nSignal    int
+}
+
+func newProblem(data []float64, lo, hi float64, model Model) *problem {
+	p := &problem{lo: lo, hi: hi}
+	if model.Signal == SignalCrystalBall {
+		p.signal = &crystalBall{lo: lo, hi: hi}
+	} else {
+		p.signal = &gaussian{lo: lo, hi: hi}
+	}
+	if model.Background == BackgroundExponential {
+		p.background = &exponential{lo: lo, hi: hi}
+	} else {
+		p.background = &polynomial{lo: lo, hi: hi, degree: model.Degree}
+	}
+	p.nSignal = len(p.signal.names())
+
+	if len(data) <= MaxUnbinnedEvents {
+		p.events = data
+		return p
+	}
+
+	p.binWidth = (hi - lo) / binnedFitBins
+	p.centers = make([]float64, binnedFitBins)
+	p.counts = make([]float64, binnedFitBins)
+	for i := range p.centers {
+		p.centers[i] = lo + (float64(i)+0.5)*p.binWidth
+	}
+	for _, x := range data {
+		bin := int((x - lo) / p.binWidth)
+		if bin >= binnedFitBins {
+			bin = binnedFitBins - 1
+		}
+		p.counts[bin]++
+	}
+	return p
+}
+
+func (p *problem) method() string {
+	if p.events != nil {
+		return "unbinned"
+	}
+	return "binned"
+}
+
+func (p *problem) names() []string {
+	names := []string{"signal_yield", "background_yield"}
+	names = append(names, p.signal.names()...)
+	return append(names, p.background.names()...)
+}
+
+// start picks starting values and simplex steps
+func (p *problem) start(model Model) ([]float64, []float64) {
+	n := p.total()
+	width := (p.hi - p.lo) / 40
+	if model.Width != nil {
+		width = math.Max(*model.Width, minWidthFraction*(p.hi-p.lo))
+	}
+	mass := (p.lo + p.hi) / 2
+	if model.Mass != nil {
+		mass = *model.Mass
+	}
+
+	x0 := []float64{0, n, mass, width}
+	step := []float64{0.1*n + 1, 0.1*n + 1, width, width / 2}
+	if _, ok := p.signal.(*crystalBall); ok {
+		x0 = append(x0, 1.5, 5)
+		step = append(step, 0.5, 2)
+	}
+	for range p.background.names() {
+		x0 = append(x0, 0)
+		step = append(step, 0.2)
+	}
+	return x0, step
+}
+
+// total returns the number of events being fitted
+func (p *problem) total() float64 {
+	if p.events != nil {
+		return float64(len(p.events))
+	}
+	var n float64
+	for _, c := range p.counts {
+		n += c
+	}
+	return n
+}
+
+// seed returns a starting point with the signal at mass: the background
+// fit plus the signal yield that best explains the events there, taken
+// from the background so the total stays fixed
+func (p *problem) seed(background []float64, mass, width float64) []float64 {
+	x := append([]float64(nil), background...)
+	x[2], x[3] = mass, width
+	nBkg := background[1]
+
+	f := func(nSig float64) float64 {
+		x[0], x[1] = nSig, nBkg-nSig
+		return p.nll(x)
+	}
+
+	// Golden-section search over the signal yield
+	a, b := 0.0, nBkg/2
+	const ratio = 0.6180339887498949
+	c, d := b-ratio*(b-a), a+ratio*(b-a)
+	fc, fd := f(c), f(d)
+	for i := 0; i < 30; i++ {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = f(d)
+		}
+	}
+	nSig := (a + b) / 2
+	x[0], x[1] = nSig, nBkg-nSig
+	return x
+}
+
+// scan looks for the largest local excess over the background fit by
+// seeding the signal at masses one width apart across the range
+func (p *problem) scan(background []float64, width float64) []float64 {
+	best := p.seed(background, (p.lo+p.hi)/2, width)
+	bestNLL := p.nll(best)
+	for mass := p.lo + 2*width; mass <= p.hi-2*width; mass += width {
+		x := p.seed(background, mass, width)
+		if value := p.nll(x); value < bestNLL {
+			best, bestNLL = x, value
+		}
+	}
+	return best
+}
+
+// minimize runs Nelder–Mead twice, the second time restarted from the first
+// result, which guards against a prematurely collapsed simplex. With
+// backgroundOnly the signal yield is held at zero.
+func (p *problem) minimize(x0, step []float64, backgroundOnly bool) ([]float64, float64, int, bool) {
+	f := p.nll
+	if backgroundOnly {
+		f = func(x []float64) float64 {
+			full := append([]float64{0}, x...)
+			return p.nll(full)
+		}
+		x0, step = x0[1:], step[1:]
+	}
+
+	var best []float64
+	var value float64
+	evals := 0
+	converged := false
+	for pass := 0; pass < 2; pass++ {
+		var n int
+		best, value, n, converged = minimize(f, x0, step, maxEvals)
+		evals += n
+		x0 = best
+	}
+
+	if backgroundOnly {
+		best = append([]float64{0}, best...)
+	}
+	return best, value, evals, converged
+}
+
+// nll is the extended negative log-likelihood, up to a constant
+func (p *problem) nll(x []float64) float64 {
+	nSig, nBkg := x[0], x[1]
+	sigParams := x[2 : 2+p.nSignal]
+	bkgParams := x[2+p.nSignal:]
+	if nBkg < 0 || nSig+nBkg <= 0 || !p.signal.prepare(sigParams) || !p.background.prepare(bkgParams) {
+		return math.Inf(1)
+	}
+
+	rate := func(v float64) (float64, bool) {
+		s, ok := p.signal.density(v, sigParams)
+		if !ok {
+			return 0, false
+		}
+		b, ok := p.background.density(v, bkgParams)
+		if !ok {
+			return 0, false
+		}
+		r := nSig*s + nBkg*b
+		return r, r > 0
+	}
+
+	sum := nSig + nBkg
+	if p.events != nil {
+		for _, v := range p.events {
+			r, ok := rate(v)
+			if !ok {
+				return math.Inf(1)
+			}
+			sum -= math.Log(r)
+		}
+		return sum
+	}
+
+	// Binned: Poisson terms with the expected count taken at the bin centre
+	for i, center := range p.centers {
+		r, ok := rate(center)
+		if !ok {
+			return math.Inf(1)
+		}
+		if p.counts[i] > 0 {
+			sum -= p.counts[i] * math.Log(r*p.binWidth)
+		}
+	}
+	return sum
+}
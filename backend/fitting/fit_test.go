@@ -0,0 +1,167 @@
+package fitting
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// exponentialSample draws n values on [lo, hi] from a falling exponential
+// with decay length tau
+func exponentialSample(rng *rand.Rand, n int, lo, hi, tau float64) []float64 {
+	values := make([]float64, 0, n)
+	for len(values) < n {
+		if x := lo + rng.ExpFloat64()*tau; x <= hi {
+			values = append(values, x)
+		}
+	}
+	return values
+}
+
+func TestFitFindsInjectedSignal(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	values := exponentialSample(rng, 10000, 100, 160, 40)
+	for i := 0; i < 500; i++ {
+		values = append(values, 125+2*rng.NormFloat64())
+	}
+
+	for _, background := range []string{BackgroundPolynomial, BackgroundExponential} {
+		t.Run(background, func(t *testing.T) {
+			model := Model{Background: background, Range: &[2]float64{100, 160}}
+			if background == BackgroundPolynomial {
+				model.Degree = 2
+			}
+			result, err := Fit(values, model)
+			if err != nil {
+				t.Fatalf("Fit: %v", err)
+			}
+			for _, check := range []struct {
+				name     string
+				estimate Estimate
+				want     float64
+			}{
+				{"mass", result.Mass, 125},
+				{"width", result.Width, 2},
+				{"signal yield", result.SignalYield, 500},
+			} {
+				if math.Abs(check.estimate.Value-check.want) > 3*check.estimate.Error {
+					t.Errorf("%s = %v, want %v within 3σ", check.name, check.estimate, check.want)
+				}
+			}
+			if result.Significance < 10 {
+				t.Errorf("significance = %v, want a clear signal", result.Significance)
+			}
+			if !result.CovarianceOK {
+				t.Error("covariance was not computed")
+			}
+		})
+	}
+}
+
+func TestFitKeepsPeakInRange(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	values := exponentialSample(rng, 5000, 100, 160, 20)
+
+	for _, signal := range []string{SignalGaussian, SignalCrystalBall} {
+		t.Run(signal, func(t *testing.T) {
+			result, err := Fit(values, Model{Signal: signal, Degree: 2})
+			if err != nil {
+				// Failing to fit pure background is acceptable; a wild
+				// result is not
+				return
+			}
+			if result.Significance > 5 {
+				t.Errorf("significance = %v on pure background", result.Significance)
+			}
+			lo, hi := result.Model.Range[0], result.Model.Range[1]
+			if result.Mass.Value < lo || result.Mass.Value > hi {
+				t.Errorf("mass = %v, outside the range [%v, %v]", result.Mass.Value, lo, hi)
+			}
+			if result.Width.Value < minWidthFraction*(hi-lo) {
+				t.Errorf("width = %v, below the minimum", result.Width.Value)
+			}
+			if _, err := json.Marshal(result); err != nil {
+				t.Errorf("result does not encode: %v", err)
+			}
+		})
+	}
+}
+
+func TestFitEvenlySpacedPointsStaysFinite(t *testing.T) {
+	values := make([]float64, 12)
+	for i := range values {
+		values[i] = float64(i)
+	}
+
+	result, err := Fit(values, Model{})
+	if err != nil {
+		return
+	}
+	if _, err := json.Marshal(result); err != nil {
+		t.Fatalf("result does not encode: %v", err)
+	}
+	if math.IsInf(result.Significance, 0) || math.IsInf(result.NLL, 0) {
+		t.Fatalf("non-finite result: nll %v, significance %v", result.NLL, result.Significance)
+	}
+}
+
+func TestFitRejectsMassOutsideRange(t *testing.T) {
+	values := make([]float64, 20)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	mass := 50.0
+	if _, err := Fit(values, Model{Mass: &mass}); err == nil {
+		t.Fatal("Fit accepted a starting mass outside the range")
+	}
+}
+
+func TestModelValidate(t *testing.T) {
+	width := -1.0
+	tests := []struct {
+		name  string
+		model Model
+		ok    bool
+	}{
+		{"defaults", Model{}, true},
+		{"unknown signal", Model{Signal: "lorentzian"}, false},
+		{"unknown background", Model{Background: "flat"}, false},
+		{"degree too high", Model{Degree: MaxPolynomialDegree + 1}, false},
+		{"degree with exponential", Model{Background: BackgroundExponential, Degree: 1}, false},
+		{"empty range", Model{Range: &[2]float64{5, 5}}, false},
+		{"negative width", Model{Width: &width}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.model.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestInvert(t *testing.T) {
+	inverse, err := invert([][]float64{{4, 2}, {2, 3}})
+	if err != nil {
+		t.Fatalf("invert: %v", err)
+	}
+	want := [][]float64{{0.375, -0.25}, {-0.25, 0.5}}
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(inverse[i][j]-want[i][j]) > 1e-12 {
+				t.Errorf("inverse[%d][%d] = %v, want %v", i, j, inverse[i][j], want[i][j])
+			}
+		}
+	}
+
+	for name, m := range map[string][][]float64{
+		"singular":     {{1, 2}, {2, 4}},
+		"not positive": {{-1, 0}, {0, 1}},
+		"NaN diagonal": {{math.NaN(), 0}, {0, 1}},
+	} {
+		if _, err := invert(m); err == nil {
+			t.Errorf("%s: invert accepted the matrix", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package fitting
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// minimize finds a local minimum of f with the Nelder–Mead simplex method,
+// starting from x0 with initial steps step. f may return +Inf to reject a
+// point. It returns the best point, its value, the number of evaluations
+// and whether the simplex converged within maxEvals.
+func minimize(f func([]float64) float64, x0, step []float64, maxEvals int) ([]float64, float64, int, bool) {
+	n := len(x0)
+	type vertex struct {
+		x []float64
+		f float64
+	}
+
+	evals := 0
+	eval := func(x []float64) float64 {
+		evals++
+		return f(x)
+	}
+
+	simplex := make([]vertex, n+1)
+	simplex[0] = vertex{append([]float64(nil), x0...), eval(x0)}
+	for i := 0; i < n; i++ {
+		x := append([]float64(nil), x0...)
+		x[i] += step[i]
+		fx := eval(x)
+		if math.IsInf(fx, 1) {
+			// Step the other way when the first try leaves the domain
+			x[i] = x0[i] - step[i]
+			fx = eval(x)
+		}
+		simplex[i+1] = vertex{x, fx}
+	}
+
+	along := func(from, to []float64, t float64) []float64 {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = from[i] + t*(to[i]-from[i])
+		}
+		return x
+	}
+
+	for evals < maxEvals {
+		sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+
+		best, worst := simplex[0].f, simplex[n].f
+		if !math.IsInf(worst, 1) && math.Abs(worst-best) <= 1e-10*(math.Abs(best)+math.Abs(worst))+1e-12 {
+			return simplex[0].x, simplex[0].f, evals, true
+		}
+
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			for i := range centroid {
+				centroid[i] += v.x[i] / float64(n)
+			}
+		}
+
+		reflected := along(centroid, simplex[n].x, -1)
+		fr := eval(reflected)
+		switch {
+		case fr < simplex[0].f:
+			expanded := along(centroid, simplex[n].x, -2)
+			if fe := eval(expanded); fe < fr {
+				simplex[n] = vertex{expanded, fe}
+			} else {
+				simplex[n] = vertex{reflected, fr}
+			}
+		case fr < simplex[n-1].f:
+			simplex[n] = vertex{reflected, fr}
+		default:
+			contracted := along(centroid, simplex[n].x, 0.5)
+			if fr < simplex[n].f {
+				contracted = along(centroid, reflected, 0.5)
+			}
+			if fc := eval(contracted); fc < math.Min(fr, simplex[n].f) {
+				simplex[n] = vertex{contracted, fc}
+				continue
+			}
+			// Shrink towards the best vertex
+			for i := 1; i <= n; i++ {
+				x := along(simplex[0].x, simplex[i].x, 0.5)
+				simplex[i] = vertex{x, eval(x)}
+			}
+		}
+	}
+
+	sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+	return simplex[0].x, simplex[0].f, evals, false
+}
+
+// hessian estimates the matrix of second derivatives of f at x by central
+// differences with per-parameter steps h
+func hessian(f func([]float64) float64, x, h []float64) [][]float64 {
+	n := len(x)
+	at := func(shifts map[int]float64) float64 {
+		y := append([]float64(nil), x...)
+		for i, s := range shifts {
+			y[i] += s
+		}
+		return f(y)
+	}
+
+	f0 := f(x)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		m[i][i] = (at(map[int]float64{i: h[i]}) - 2*f0 + at(map[int]float64{i: -h[i]})) / (h[i] * h[i])
+		for j := 0; j < i; j++ {
+			m[i][j] = (at(map[int]float64{i: h[i], j: h[j]}) -
+				at(map[int]float64{i: h[i], j: -h[j]}) -
+				at(map[int]float64{i: -h[i], j: h[j]}) +
+				at(map[int]float64{i: -h[i], j: -h[j]})) / (4 * h[i] * h[j])
+			m[j][i] = m[i][j]
+		}
+	}
+	return m
+}
+
+// invert returns the inverse of a symmetric positive definite matrix
+func invert(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	// Gauss–Jordan elimination with partial pivoting on [a | I]
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-300 || math.IsNaN(m[pivot][col]) {
+			return nil, fmt.Errorf("matrix is singular")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		scale := m[col][col]
+		for k := range m[col] {
+			m[col][k] /= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || m[row][col] == 0 {
+				continue
+			}
+			factor := m[row][col]
+			for k := range m[row] {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+
+	inverse := make([][]float64, n)
+	for i := range inverse {
+		inverse[i] = m[i][n:]
+		if !(inverse[i][i] > 0) {
+			return nil, fmt.Errorf("matrix is not positive definite")
+		}
+	}
+	return inverse, nil
+}
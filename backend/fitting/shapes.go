@@ -0,0 +1,166 @@
+package fitting
+
+import (
+	"fmt"
+	"math"
+)
+
+// shape is a probability density on [lo, hi] with its parameters taken from
+// a slice of the full parameter vector
+type shape interface {
+	// names lists the parameters the shape reads, in order
+	names() []string
+	// density returns the normalized density at x, or ok=false when the
+	// parameters are outside their domain
+	density(x float64, p []float64) (value float64, ok bool)
+	// prepare computes anything that depends only on the parameters, such
+	// as the normalization, before a batch of density calls
+	prepare(p []float64) bool
+}
+
+// minWidthFraction is the narrowest signal width allowed, as a fraction of
+// the fit range; it is the bin width of binned fits. A narrower peak can
+// collapse onto a single event and drive the likelihood to infinity.
+const minWidthFraction = 1.0 / binnedFitBins
+
+// peakInRange reports whether a peak at mu with width sigma lies inside
+// [lo, hi] and is at least the minimum width
+func peakInRange(mu, sigma, lo, hi float64) bool {
+	return mu >= lo && mu <= hi && sigma >= minWidthFraction*(hi-lo)
+}
+
+// gaussian is a normal density truncated to the fit range.
+// Parameters: mass, width.
+type gaussian struct {
+	lo, hi float64
+	norm   float64
+}
+
+func (g *gaussian) names() []string { return []string{"mass", "width"} }
+
+func (g *gaussian) prepare(p []float64) bool {
+	mu, sigma := p[0], p[1]
+	if !peakInRange(mu, sigma, g.lo, g.hi) {
+		return false
+	}
+	a, b := (g.lo-mu)/sigma, (g.hi-mu)/sigma
+	g.norm = sigma * math.Sqrt(math.Pi/2) * (math.Erf(b/math.Sqrt2) - math.Erf(a/math.Sqrt2))
+	return g.norm > 0
+}
+
+func (g *gaussian) density(x float64, p []float64) (float64, bool) {
+	t := (x - p[0]) / p[1]
+	return math.Exp(-t*t/2) / g.norm, true
+}
+
+// crystalBall is a Gaussian core with a power-law low-mass tail, the usual
+// line shape for calorimeter photons that lose energy before the
+// calorimeter. Parameters: mass, width, alpha (where the tail starts, in
+// widths below the peak) and n (the tail exponent).
+type crystalBall struct {
+	lo, hi float64
+	norm   float64
+	a, b   float64 // tail constants A and B
+}
+
+func (c *crystalBall) names() []string { return []string{"mass", "width", "alpha", "n"} }
+
+func (c *crystalBall) prepare(p []float64) bool {
+	mu, sigma, alpha, n := p[0], p[1], p[2], p[3]
+	if !peakInRange(mu, sigma, c.lo, c.hi) || alpha <= 0 || n <= 1 || alpha > 10 || n > 200 {
+		return false
+	}
+	c.a = math.Pow(n/alpha, n) * math.Exp(-alpha*alpha/2)
+	c.b = n/alpha - alpha
+
+	// Integrate in t = (x-mu)/sigma: power-law tail below -alpha and the
+	// Gaussian core above it
+	ta, tb := (c.lo-mu)/sigma, (c.hi-mu)/sigma
+	var integral float64
+	if ta < -alpha {
+		upper := math.Min(tb, -alpha)
+		integral += c.a / (n - 1) * (math.Pow(c.b-upper, 1-n) - math.Pow(c.b-ta, 1-n))
+	}
+	if tb > -alpha {
+		lower := math.Max(ta, -alpha)
+		integral += math.Sqrt(math.Pi/2) * (math.Erf(tb/math.Sqrt2) - math.Erf(lower/math.Sqrt2))
+	}
+	c.norm = sigma * integral
+	return c.norm > 0 && !math.IsInf(c.norm, 0) && !math.IsNaN(c.norm)
+}
+
+func (c *crystalBall) density(x float64, p []float64) (float64, bool) {
+	t := (x - p[0]) / p[1]
+	if t > -p[2] {
+		return math.Exp(-t*t/2) / c.norm, true
+	}
+	return c.a * math.Pow(c.b-t, -p[3]) / c.norm, true
+}
+
+// polynomial is 1 + Σ c_k T_k(t) in the Chebyshev polynomials T_k, with t
+// the position mapped onto [-1, 1]. Chebyshev terms keep the coefficients
+// weakly correlated. Parameters: c1 … c_degree.
+type polynomial struct {
+	lo, hi float64
+	degree int
+	norm   float64
+}
+
+func (q *polynomial) names() []string {
+	names := make([]string, q.degree)
+	for k := range names {
+		names[k] = fmt.Sprintf("c%d", k+1)
+	}
+	return names
+}
+
+func (q *polynomial) prepare(p []float64) bool {
+	// ∫ T_k over [-1, 1] is 0 for odd k and 2/(1-k²) for even k
+	integral := 2.0
+	for k := 2; k <= q.degree; k += 2 {
+		integral += p[k-1] * 2 / float64(1-k*k)
+	}
+	q.norm = integral * (q.hi - q.lo) / 2
+	return q.norm > 0
+}
+
+func (q *polynomial) density(x float64, p []float64) (float64, bool) {
+	t := 2*(x-q.lo)/(q.hi-q.lo) - 1
+	value := 1.0
+	prev, cur := 1.0, t // T_0, T_1
+	for k := 1; k <= q.degree; k++ {
+		value += p[k-1] * cur
+		prev, cur = cur, 2*t*cur-prev
+	}
+	if value <= 0 {
+		return 0, false
+	}
+	return value / q.norm, true
+}
+
+// exponential is exp(slope·t) with t the position mapped onto [-1, 1].
+// Parameters: slope.
+type exponential struct {
+	lo, hi float64
+	norm   float64
+}
+
+func (e *exponential) names() []string { return []string{"slope"} }
+
+func (e *exponential) prepare(p []float64) bool {
+	slope := p[0]
+	if math.Abs(slope) > 500 {
+		return false
+	}
+	integral := 2.0
+	if math.Abs(slope) > 1e-9 {
+		integral = 2 * math.Sinh(slope) / slope
+	}
+	e.norm = integral * (e.hi - e.lo) / 2
+	return true
+}
+
+func (e *exponential) density(x float64, p []float64) (float64, bool) {
+	t := 2*(x-e.lo)/(e.hi-e.lo) - 1
+	return math.Exp(p[0]*t) / e.norm, true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"fresherpaint/backend/benchmark"
+	"fresherpaint/backend/generators"
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/schema"
+)
+
+// GeneratorSummary describes a registered generator
+type GeneratorSummary struct {
+	Name        string               `json:"name"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	DataType    models.AnalyticsType `json:"data_type"`
+	Parameters  *schema.Schema       `json:"parameters"`
+	Defaults    interface{}          `json:"defaults"`
+}
+
+// GeneratorRunRequest is the body of POST /api/generators/{name}/run. Title
+// and description default to the generator's; omitted parameters take their
+// defaults.
+type GeneratorRunRequest struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+func summarizeGenerator(g generators.Generator) GeneratorSummary {
+	return GeneratorSummary{
+		Name:        g.Name(),
+		Title:       g.Title(),
+		Description: g.Description(),
+		DataType:    g.DataType(),
+		Parameters:  g.ParamSchema(),
+		Defaults:    g.Defaults(),
+	}
+}
+
+// listGeneratorsHandler serves GET /api/generators
+func listGeneratorsHandler(w http.ResponseWriter, r *http.Request) {
+	summaries := []GeneratorSummary{}
+	for _, g := range generators.All() {
+		summaries = append(summaries, summarizeGenerator(g))
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: summaries})
+}
+
+// getGeneratorHandler serves GET /api/generators/{name}
+func getGeneratorHandler(w http.ResponseWriter, r *http.Request) {
+	g, ok := generators.Lookup(r.PathValue("name"))
+	if !ok {
+		writeError(w, notFound("Generator"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: summarizeGenerator(g)})
+}
+
+// runGeneratorHandler serves POST /api/generators/{name}/run
+func runGeneratorHandler(w http.ResponseWriter, r *http.Request) {
+	g, ok := generators.Lookup(r.PathValue("name"))
+	if !ok {
+		writeError(w, notFound("Generator"))
+		return
+	}
+
+	var req GeneratorRunRequest
+	if err := decodeDatasetBody(w, r, &req); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+	runGenerator(w, g, req)
+}
+
+// runGenerator generates a dataset and stores it, answering 201 with the new
+// row. Invalid parameters are rejected with 422 and their field errors.
+func runGenerator(w http.ResponseWriter, g generators.Generator, req GeneratorRunRequest) {
+	input := models.AnalyticsDataInput{
+		Title:       req.Title,
+		Description: req.Description,
+		DataType:    g.DataType(),
+		Data:        json.RawMessage("{}"), // replaced by the generated payload
+	}
+	if input.Title == "" {
+		input.Title = g.Title()
+	}
+	if input.Description == "" {
+		input.Description = g.Description()
+	}
+
+	// Reject bad metadata before spending time on generation
+	if err := input.Validate(datasetTypes.Has); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	dataset, err := g.Generate(req.Parameters)
+	var paramErr *generators.ParamError
+	switch {
+	case errors.As(err, &paramErr):
+		apiErr := apiError(CodeValidation, "%s", paramErr.Error())
+		if len(paramErr.Fields) > 0 {
+			apiErr.Details = paramErr.Fields
+		}
+		writeError(w, apiErr)
+		return
+	case errors.Is(err, benchmark.ErrBusy):
+		writeError(w, apiError(CodeConflict, "A benchmark is already running; try again when it has finished"))
+		return
+	case err != nil:
+		writeError(w, internalError("Failed to generate dataset", err))
+		return
+	}
+
+	data, err := json.Marshal(dataset.Data)
+	if err != nil {
+		writeError(w, internalError("Failed to encode payload", err))
+		return
+	}
+	if errs := validateDatasetPayload(input.DataType, data); len(errs) > 0 {
+		writeError(w, schemaErrors(errs))
+		return
+	}
+
+	row := database.GetDB().QueryRow(`
+		INSERT INTO analytics_data (title, description, data_type, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING `+analyticsDataColumns,
+		input.Title, input.Description, input.DataType, data,
+	)
+
+	item, err := scanAnalyticsData(row)
+	if err != nil {
+		writeError(w, datasetStoreError(err, &input.DataType, "Failed to store dataset"))
+		return
+	}
+
+	w.Header().Set("Location", "/api/analytics/"+item.ID)
+	writeJSON(w, http.StatusCreated, APIResponse{Success: true, Data: item})
+}
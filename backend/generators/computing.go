@@ -0,0 +1,184 @@
+package generators
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"fresherpaint/backend/benchmark"
+	"fresherpaint/backend/models"
+)
+
+// TrainingParams configures the model training curves
+type TrainingParams struct {
+	Seed   int64 `json:"seed"`
+	Epochs int   `json:"epochs"`
+}
+
+// NetworkParams configures the 5G metrics
+type NetworkParams struct {
+	Seed  int64     `json:"seed"`
+	Hours int       `json:"hours"`
+	Start time.Time `json:"start"` // timestamp of the first hourly sample
+}
+
+// sortingGenerator generates its keys from the seed, but the runtimes are
+// measured on the host and differ from run to run. It is not seeded, so a
+// server start does not spend seconds benchmarking; run it through
+// POST /api/generators/sorting_benchmarks/run instead.
+var sortingGenerator = &builtin[benchmark.Config]{
+	name:        "sorting_benchmarks",
+	title:       "Modern Sorting Algorithm Performance",
+	description: "Measured runtimes of sorting algorithms, median of repeated runs",
+	dataType:    models.AnalyticsTypeCS,
+	params:      paramSchema("sorting_benchmarks"),
+	defaults:    benchmark.DefaultConfig,
+	check:       (*benchmark.Config).Validate,
+	onDemand:    true,
+	generate: func(config benchmark.Config) (map[string]interface{}, error) {
+		result, err := benchmark.Run(config)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"algorithms":       result.Series,
+			"test_environment": result.Environment,
+			"runtime_unit":     result.RuntimeUnit,
+			"started_at":       result.StartedAt,
+			"benchmark":        result.Config,
+		}, nil
+	},
+}
+
+var trainingGenerator = &builtin[TrainingParams]{
+	name:        "ml_training",
+	title:       "Deep Learning Model Training Metrics",
+	description: "Training performance data for various neural network architectures",
+	dataType:    models.AnalyticsTypeCS,
+	params:      paramSchema("ml_training"),
+	defaults: func() TrainingParams {
+		return TrainingParams{Seed: 1, Epochs: 100}
+	},
+	generate: func(p TrainingParams) (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"models":   trainingCurves(p),
+			"dataset":  "ImageNet-1K",
+			"hardware": "NVIDIA RTX 4090",
+		}, nil
+	},
+}
+
+var networkGenerator = &builtin[NetworkParams]{
+	name:        "network_5g",
+	title:       "5G Network Performance Analysis",
+	description: "Real-world 5G network performance metrics across different conditions",
+	dataType:    models.AnalyticsTypeCS,
+	params:      paramSchema("network_5g"),
+	defaults: func() NetworkParams {
+		return NetworkParams{Seed: 1, Hours: 24, Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}
+	},
+	generate: func(p NetworkParams) (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"metrics":        networkMetrics(p),
+			"test_locations": []string{"Urban", "Suburban", "Rural"},
+			"units": map[string]string{
+				"bandwidth":   "Mbps",
+				"latency":     "ms",
+				"packet_loss": "%",
+			},
+		}, nil
+	},
+}
+
+// trainingCurves generates accuracy and loss per epoch for two architectures
+func trainingCurves(p TrainingParams) []map[string]interface{} {
+	models := []map[string]interface{}{}
+	rng := rand.New(rand.NewSource(p.Seed))
+
+	// ResNet-50 training data
+	resnetEpochs := []int{}
+	resnetAccuracy := []float64{}
+	resnetLoss := []float64{}
+
+	for epoch := 1; epoch <= p.Epochs; epoch++ {
+		resnetEpochs = append(resnetEpochs, epoch)
+		// Realistic training curve
+		accuracy := 0.1 + 0.65*(1-math.Exp(-float64(epoch)/20)) + rng.Float64()*0.05
+		loss := 2.3*math.Exp(-float64(epoch)/15) + 0.1 + rng.Float64()*0.1
+		resnetAccuracy = append(resnetAccuracy, math.Round(accuracy*1000)/1000)
+		resnetLoss = append(resnetLoss, math.Round(loss*1000)/1000)
+	}
+
+	models = append(models, map[string]interface{}{
+		"name":                    "ResNet-50",
+		"epochs":                  resnetEpochs,
+		"accuracy":                resnetAccuracy,
+		"loss":                    resnetLoss,
+		"parameters":              "25.6M",
+		"training_time_per_epoch": "4.2 minutes",
+	})
+
+	// Vision Transformer training data
+	vitEpochs := []int{}
+	vitAccuracy := []float64{}
+	vitLoss := []float64{}
+
+	for epoch := 1; epoch <= p.Epochs; epoch++ {
+		vitEpochs = append(vitEpochs, epoch)
+		// ViT typically starts slower but reaches higher accuracy
+		accuracy := 0.05 + 0.75*(1-math.Exp(-float64(epoch)/25)) + rng.Float64()*0.03
+		loss := 2.8*math.Exp(-float64(epoch)/18) + 0.08 + rng.Float64()*0.08
+		vitAccuracy = append(vitAccuracy, math.Round(accuracy*1000)/1000)
+		vitLoss = append(vitLoss, math.Round(loss*1000)/1000)
+	}
+
+	models = append(models, map[string]interface{}{
+		"name":                    "Vision Transformer",
+		"epochs":                  vitEpochs,
+		"accuracy":                vitAccuracy,
+		"loss":                    vitLoss,
+		"parameters":              "86.6M",
+		"training_time_per_epoch": "6.8 minutes",
+	})
+
+	return models
+}
+
+// networkMetrics generates hourly 5G measurements whose load follows the time of day
+func networkMetrics(p NetworkParams) []map[string]interface{} {
+	metrics := []map[string]interface{}{}
+	rng := rand.New(rand.NewSource(p.Seed))
+
+	for i := 0; i < p.Hours; i++ {
+		at := p.Start.Add(time.Duration(i) * time.Hour).UTC()
+		hour := at.Hour()
+
+		// Realistic 5G performance varies by time of day
+		var bandwidth, latency, packetLoss float64
+
+		if hour >= 8 && hour <= 18 { // Business hours - higher load
+			bandwidth = 800 + rng.Float64()*400 // 800-1200 Mbps
+			latency = 8 + rng.Float64()*12      // 8-20 ms
+			packetLoss = rng.Float64() * 0.5    // 0-0.5%
+		} else if hour >= 19 && hour <= 23 { // Evening peak
+			bandwidth = 600 + rng.Float64()*600 // 600-1200 Mbps
+			latency = 10 + rng.Float64()*15     // 10-25 ms
+			packetLoss = rng.Float64() * 0.8    // 0-0.8%
+		} else { // Night/early morning - low load
+			bandwidth = 1000 + rng.Float64()*500 // 1000-1500 Mbps
+			latency = 3 + rng.Float64()*7        // 3-10 ms
+			packetLoss = rng.Float64() * 0.2     // 0-0.2%
+		}
+
+		metrics = append(metrics, map[string]interface{}{
+			"timestamp":       at.Format(time.RFC3339),
+			"bandwidth":       math.Round(bandwidth*10) / 10,
+			"latency":         math.Round(latency*10) / 10,
+			"packet_loss":     math.Round(packetLoss*1000) / 1000,
+			"signal_strength": -70 + rng.Float64()*20, // dBm
+			"user_count":      50 + rng.Intn(200),
+		})
+	}
+
+	return metrics
+}
@@ -0,0 +1,178 @@
+// Package generators produces synthetic datasets. Each generator has a name,
+// a JSON Schema for its parameters and defaults for every parameter, and
+// turns a seed and parameters into a dataset payload deterministically.
+package generators
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/schema"
+)
+
+// Parameter schemas are embedded as params/<generator name>.json
+//
+//go:embed params/*.json
+var paramFiles embed.FS
+
+// Generator produces a dataset from parameters
+type Generator interface {
+	Name() string
+	Title() string
+	Description() string
+	DataType() models.AnalyticsType
+	// ParamSchema is the JSON Schema of the parameters object
+	ParamSchema() *schema.Schema
+	// Defaults returns the parameters used for fields the caller omits
+	Defaults() interface{}
+	// Generate produces a dataset from a JSON parameters object; omitted
+	// fields take their defaults. Invalid parameters return a *ParamError.
+	Generate(params json.RawMessage) (*Dataset, error)
+}
+
+// Dataset is a generated dataset ready to be stored
+type Dataset struct {
+	Title       string
+	Description string
+	DataType    models.AnalyticsType
+	Data        map[string]interface{}
+}
+
+// ParamError reports parameters that do not match a generator's schema or
+// limits
+type ParamError struct {
+	Message string
+	Fields  []schema.FieldError
+}
+
+func (e *ParamError) Error() string {
+	if len(e.Fields) > 0 {
+		return fmt.Sprintf("invalid parameters: %s", e.Fields[0].Error())
+	}
+	return "invalid parameters: " + e.Message
+}
+
+// registry holds the generators in registration order
+var registry []Generator
+
+// The built-in generators, in the order they are listed and seeded
+func init() {
+	for _, g := range []Generator{
+		higgsGenerator,
+		muonGenerator,
+		bellGenerator,
+		sortingGenerator,
+		trainingGenerator,
+		networkGenerator,
+	} {
+		Register(g)
+	}
+}
+
+// Register adds a generator; it panics when the name is taken
+func Register(g Generator) {
+	if _, ok := Lookup(g.Name()); ok {
+		panic(fmt.Sprintf("generators: %s registered twice", g.Name()))
+	}
+	registry = append(registry, g)
+}
+
+// All returns every registered generator in registration order
+func All() []Generator {
+	return append([]Generator(nil), registry...)
+}
+
+// Seeded returns the generators whose output is stored as a built-in dataset,
+// in registration order. Generators marked on-demand only run through the
+// API, because their output depends on the host rather than on the seed.
+func Seeded() []Generator {
+	var seeded []Generator
+	for _, g := range registry {
+		if o, ok := g.(interface{ OnDemand() bool }); ok && o.OnDemand() {
+			continue
+		}
+		seeded = append(seeded, g)
+	}
+	return seeded
+}
+
+// Lookup finds a generator by name
+func Lookup(name string) (Generator, bool) {
+	for _, g := range registry {
+		if g.Name() == name {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// builtin implements Generator for a typed parameter struct P
+type builtin[P any] struct {
+	name        string
+	title       string
+	description string
+	dataType    models.AnalyticsType
+	params      *schema.Schema
+	defaults    func() P
+	// onDemand keeps the generator out of the built-in datasets
+	onDemand bool
+	// check applies limits the schema cannot express; it may fill in
+	// derived defaults
+	check    func(*P) error
+	generate func(P) (map[string]interface{}, error)
+}
+
+func (b *builtin[P]) Name() string                   { return b.name }
+func (b *builtin[P]) Title() string                  { return b.title }
+func (b *builtin[P]) Description() string            { return b.description }
+func (b *builtin[P]) DataType() models.AnalyticsType { return b.dataType }
+func (b *builtin[P]) ParamSchema() *schema.Schema    { return b.params }
+func (b *builtin[P]) Defaults() interface{}          { return b.defaults() }
+func (b *builtin[P]) OnDemand() bool                 { return b.onDemand }
+
+// Generate validates the parameters against the schema, decodes them over
+// the defaults and records the effective parameters under "generator" in
+// the payload, so the dataset can be reproduced
+func (b *builtin[P]) Generate(raw json.RawMessage) (*Dataset, error) {
+	p := b.defaults()
+	if trimmed := strings.TrimSpace(string(raw)); trimmed != "" && trimmed != "null" {
+		var decoded interface{}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			return nil, &ParamError{Message: "parameters must be valid JSON"}
+		}
+		if errs := b.params.Validate("parameters", decoded); len(errs) > 0 {
+			return nil, &ParamError{Message: "parameters do not match the schema", Fields: errs}
+		}
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, &ParamError{Message: err.Error()}
+		}
+	}
+	if b.check != nil {
+		if err := b.check(&p); err != nil {
+			return nil, &ParamError{Message: err.Error()}
+		}
+	}
+
+	data, err := b.generate(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.name, err)
+	}
+	data["generator"] = map[string]interface{}{
+		"name":       b.name,
+		"parameters": p,
+	}
+	return &Dataset{Title: b.title, Description: b.description, DataType: b.dataType, Data: data}, nil
+}
+
+// paramSchema compiles the embedded parameter schema of a generator
+func paramSchema(name string) *schema.Schema {
+	doc, err := paramFiles.ReadFile(path.Join("params", name+".json"))
+	if err != nil {
+		panic(fmt.Sprintf("generators: no parameter schema for %s", name))
+	}
+	return schema.MustCompile(doc)
+}
@@ -0,0 +1,265 @@
+package generators
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"fresherpaint/backend/bell"
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/montecarlo"
+)
+
+// HiggsParams configures the simulated H→γγ sample; see
+// montecarlo.DiphotonConfig
+type HiggsParams struct {
+	Seed             int64   `json:"seed"`
+	SignalEvents     int     `json:"signal_events"`
+	BackgroundEvents int     `json:"background_events"`
+	Mass             float64 `json:"mass"`  // GeV
+	Width            float64 `json:"width"` // GeV, natural width
+	SamplingTerm     float64 `json:"sampling_term"`
+	ConstantTerm     float64 `json:"constant_term"`
+	MassMin          float64 `json:"mass_min"`         // GeV
+	MassMax          float64 `json:"mass_max"`         // GeV
+	BackgroundSlope  float64 `json:"background_slope"` // GeV⁻¹
+}
+
+func (p *HiggsParams) config() montecarlo.DiphotonConfig {
+	return montecarlo.DiphotonConfig{
+		SignalEvents:     p.SignalEvents,
+		BackgroundEvents: p.BackgroundEvents,
+		Mass:             p.Mass,
+		Width:            p.Width,
+		SamplingTerm:     p.SamplingTerm,
+		ConstantTerm:     p.ConstantTerm,
+		MassMin:          p.MassMin,
+		MassMax:          p.MassMax,
+		BackgroundSlope:  p.BackgroundSlope,
+	}
+}
+
+// MuonParams configures the simulated cosmic muon sample; see
+// montecarlo.MuonConfig
+type MuonParams struct {
+	Seed              int64   `json:"seed"`
+	Events            int     `json:"events"`
+	MinEnergy         float64 `json:"min_energy"` // GeV
+	MaxEnergy         float64 `json:"max_energy"` // GeV
+	SpectralIndex     float64 `json:"spectral_index"`
+	DetectorThickness float64 `json:"detector_thickness"` // cm
+}
+
+func (p *MuonParams) config() montecarlo.MuonConfig {
+	return montecarlo.MuonConfig{
+		Events:            p.Events,
+		MinEnergy:         p.MinEnergy,
+		MaxEnergy:         p.MaxEnergy,
+		SpectralIndex:     p.SpectralIndex,
+		DetectorThickness: p.DetectorThickness,
+	}
+}
+
+// BellParams configures the Bell test correlations
+type BellParams struct {
+	Seed  int64   `json:"seed"`
+	Noise float64 `json:"noise"` // full width of the uniform noise added to each correlation
+}
+
+var higgsGenerator = &builtin[HiggsParams]{
+	name:        "higgs_diphoton",
+	title:       "LHC Higgs Boson Decay Analysis",
+	description: "Simulated ATLAS-like H→γγ candidates over the diphoton continuum, with photon four-momenta",
+	dataType:    models.AnalyticsTypePhysics,
+	params:      paramSchema("higgs_diphoton"),
+	defaults: func() HiggsParams {
+		return HiggsParams{
+			Seed:             1,
+			SignalEvents:     200,
+			BackgroundEvents: 2000,
+			Mass:             125.0,
+			Width:            0.0041,
+			SamplingTerm:     0.10,
+			ConstantTerm:     0.007,
+			MassMin:          105,
+			MassMax:          160,
+			BackgroundSlope:  0.025,
+		}
+	},
+	check: func(p *HiggsParams) error {
+		config := p.config()
+		return config.Validate()
+	},
+	generate: func(p HiggsParams) (map[string]interface{}, error) {
+		sample, err := montecarlo.GenerateDiphotons(p.config(), rand.New(rand.NewSource(p.Seed)))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"experiment":       "ATLAS",
+			"collision_energy": "13TeV",
+			"luminosity":       "139 fb^-1",
+			"measurements":     diphotonMeasurements(sample),
+			"generated_events": sample.Generated,
+			"acceptance": map[string]float64{
+				montecarlo.Signal:     math.Round(sample.Acceptance(montecarlo.Signal)*1e4) / 1e4,
+				montecarlo.Background: math.Round(sample.Acceptance(montecarlo.Background)*1e4) / 1e4,
+			},
+			"units": map[string]string{
+				"energy":        "GeV",
+				"time":          "ns",
+				"momentum":      "GeV/c",
+				"four_momentum": "GeV",
+			},
+		}, nil
+	},
+}
+
+var muonGenerator = &builtin[MuonParams]{
+	name:        "cosmic_muons",
+	title:       "Cosmic Ray Muon Detection",
+	description: "Simulated sea-level cosmic muons with a Gaisser spectrum and cos² zenith distribution, with four-momenta",
+	dataType:    models.AnalyticsTypePhysics,
+	params:      paramSchema("cosmic_muons"),
+	defaults: func() MuonParams {
+		return MuonParams{
+			Seed:              1,
+			Events:            200,
+			MinEnergy:         1,
+			MaxEnergy:         1000,
+			SpectralIndex:     2.7,
+			DetectorThickness: 10,
+		}
+	},
+	check: func(p *MuonParams) error {
+		config := p.config()
+		return config.Validate()
+	},
+	generate: func(p MuonParams) (map[string]interface{}, error) {
+		muons, err := montecarlo.GenerateMuons(p.config(), rand.New(rand.NewSource(p.Seed)))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"experiment":      "CMS",
+			"detector_type":   "Muon Chambers",
+			"collisions":      muonCollisions(muons),
+			"background_rate": 2.3, // Hz/cm²
+			"units": map[string]string{
+				"momentum":      "GeV/c",
+				"energy":        "GeV",
+				"angle":         "degrees",
+				"energy_loss":   "MeV/cm",
+				"track_length":  "cm",
+				"four_momentum": "GeV",
+			},
+		}, nil
+	},
+}
+
+var bellGenerator = &builtin[BellParams]{
+	name:        "bell_entanglement",
+	title:       "Bell State Quantum Entanglement",
+	description: "Quantum entanglement correlation measurements violating Bell inequalities",
+	dataType:    models.AnalyticsTypePhysics,
+	params:      paramSchema("bell_entanglement"),
+	defaults: func() BellParams {
+		return BellParams{Seed: 1, Noise: 0.1}
+	},
+	generate: func(p BellParams) (map[string]interface{}, error) {
+		measurements := bellCorrelations(p)
+		chsh, err := bell.CHSH(bellMeasurements(measurements))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute CHSH parameter: %w", err)
+		}
+		return map[string]interface{}{
+			"experiment_type":      "Bell Test",
+			"quantum_measurements": measurements,
+			"bell_parameter":       math.Round(chsh.S*1000) / 1000, // S > 2 violates Bell inequality
+			"bell_parameter_error": math.Round(chsh.Error*1000) / 1000,
+			"bell_violation_sigma": math.Round(chsh.Sigma*10) / 10,
+			"units": map[string]string{
+				"correlation": "dimensionless",
+				"angle":       "degrees",
+			},
+		}, nil
+	},
+}
+
+// diphotonMeasurements converts accepted diphoton events to the
+// measurements payload. The invariant mass is recomputed from the stored,
+// rounded four-momenta so the two always agree.
+func diphotonMeasurements(sample *montecarlo.DiphotonSample) []map[string]interface{} {
+	measurements := make([]map[string]interface{}, 0, len(sample.Events))
+	for i, event := range sample.Events {
+		photon1, photon2 := event.Photons[0].Rounded(), event.Photons[1].Rounded()
+		measurements = append(measurements, map[string]interface{}{
+			"invariant_mass": math.Round(photon1.Add(photon2).Mass()*1000) / 1000,
+			"photon1_energy": math.Round(photon1.E*100) / 100,
+			"photon2_energy": math.Round(photon2.E*100) / 100,
+			"time":           float64(i) * 0.025, // 25 ns between bunch crossings
+			"process":        event.Process,
+			"true_mass":      math.Round(event.TrueMass*1e4) / 1e4,
+			"photon1":        photon1,
+			"photon2":        photon2,
+		})
+	}
+	return measurements
+}
+
+// muonCollisions converts simulated muons to the collisions payload
+func muonCollisions(muons []montecarlo.Muon) []map[string]interface{} {
+	collisions := make([]map[string]interface{}, 0, len(muons))
+	for _, muon := range muons {
+		p4 := muon.Momentum.Rounded()
+		collisions = append(collisions, map[string]interface{}{
+			"momentum":      math.Round(p4.P()*1000) / 1000,
+			"energy":        p4.E,
+			"angle":         math.Round(muon.Zenith*10) / 10,
+			"azimuth":       math.Round(muon.Azimuth*10) / 10,
+			"energy_loss":   math.Round(muon.EnergyLoss*100) / 100,
+			"track_length":  math.Round(muon.TrackLength*10) / 10,
+			"four_momentum": p4,
+		})
+	}
+	return collisions
+}
+
+// bellCorrelations generates polarisation correlations at the Bell test angles
+func bellCorrelations(p BellParams) []map[string]interface{} {
+	measurements := []map[string]interface{}{}
+	rng := rand.New(rand.NewSource(p.Seed))
+
+	// Bell test angles
+	angles := []float64{0, 22.5, 45, 67.5, 90, 112.5, 135, 157.5}
+
+	for _, angle := range angles {
+		// Quantum correlation should violate Bell inequality
+		correlation := -math.Cos(2 * angle * math.Pi / 180)  // Quantum prediction
+		correlation += (rng.Float64() - 0.5) * p.Noise       // Add experimental noise
+		correlation = math.Max(-1, math.Min(1, correlation)) // A correlation cannot exceed ±1
+
+		measurements = append(measurements, map[string]interface{}{
+			"angle":             angle,
+			"correlation":       math.Round(correlation*1000) / 1000,
+			"measurement_count": 1000 + rng.Intn(500),
+			"statistical_error": math.Round(rng.Float64()*0.05*1000) / 1000,
+		})
+	}
+
+	return measurements
+}
+
+// bellMeasurements converts generated quantum measurements for bell.CHSH
+func bellMeasurements(measurements []map[string]interface{}) []bell.Measurement {
+	converted := make([]bell.Measurement, len(measurements))
+	for i, m := range measurements {
+		converted[i] = bell.Measurement{
+			Angle:            m["angle"].(float64),
+			Correlation:      m["correlation"].(float64),
+			MeasurementCount: m["measurement_count"].(int),
+			StatisticalError: m["statistical_error"].(float64),
+		}
+	}
+	return converted
+}
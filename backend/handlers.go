@@ -2,7 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
+
 	"fresherpaint/backend/models"
 )
 
@@ -10,135 +14,210 @@ type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	// Code and RequestID are set on errors; see writeError
+	Code      ErrorCode   `json:"code,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Meta      *ListMeta   `json:"meta,omitempty"`
 }
 
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+func getAnalyticsDataHandler(w http.ResponseWriter, r *http.Request) {
+	listAnalyticsData(w, r, "")
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+func getAnalyticsDataByTypeHandler(w http.ResponseWriter, r *http.Request) {
+	dataType := r.URL.Query().Get("type")
+	if dataType == "" {
+		writeError(w, apiError(CodeBadRequest, "Missing type parameter"))
+		return
+	}
 
-		next(w, r)
+	if !datasetTypes.Has(models.AnalyticsType(dataType)) {
+		writeError(w, apiError(CodeBadRequest, "Unknown data type: %s", dataType))
+		return
 	}
+
+	listAnalyticsData(w, r, dataType)
 }
 
-func getAnalyticsDataHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// listAnalyticsData writes one page of datasets, optionally filtered by type
+func listAnalyticsData(w http.ResponseWriter, r *http.Request, dataType string) {
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, apiError(CodeBadRequest, "%v", err))
 		return
 	}
+	opts.DataType = dataType
 
-	// Query the database for all analytics data
-	data, err := queryAnalyticsData("")
+	data, meta, err := queryAnalyticsData(opts)
 	if err != nil {
-		response := APIResponse{
-			Success: false,
-			Error:   "Failed to fetch analytics data: " + err.Error(),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeError(w, internalError("Failed to fetch analytics data", err))
 		return
 	}
 
-	response := APIResponse{
-		Success: true,
-		Data:    data,
+	response := APIResponse{Success: true, Data: data, Meta: meta}
+	if opts.Fields != nil {
+		projected := make([]map[string]interface{}, len(data))
+		for i := range data {
+			projected[i] = projectAnalyticsData(&data[i], opts.Fields)
+		}
+		response.Data = projected
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
 }
 
-func getAnalyticsDataByTypeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// analyticsDataColumns is the column list read by scanAnalyticsData
+const analyticsDataColumns = "id, title, COALESCE(description, ''), data_type, data, created_at, COALESCE(updated_at, created_at)"
 
-	dataType := r.URL.Query().Get("type")
-	if dataType == "" {
-		http.Error(w, "Missing type parameter", http.StatusBadRequest)
-		return
+// analyticsSummaryColumns is analyticsDataColumns without the payload, for
+// lists that do not select data
+const analyticsSummaryColumns = "id, title, COALESCE(description, ''), data_type, 'null'::jsonb, created_at, COALESCE(updated_at, created_at)"
+
+// queryAnalyticsData queries one page of analytics data using keyset
+// pagination on the sort column and id, plus the total number of matches
+func queryAnalyticsData(opts *ListOptions) ([]models.AnalyticsData, *ListMeta, error) {
+	var filters []string
+	var args []interface{}
+
+	if opts.DataType != "" {
+		args = append(args, opts.DataType)
+		filters = append(filters, fmt.Sprintf("data_type = $%d", len(args)))
 	}
 
-	// Query the database with the type filter
-	data, err := queryAnalyticsData(dataType)
-	if err != nil {
-		response := APIResponse{
-			Success: false,
-			Error:   "Failed to fetch analytics data: " + err.Error(),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+	meta := &ListMeta{Limit: opts.Limit}
+	countQuery := "SELECT COUNT(*) FROM analytics_data"
+	if len(filters) > 0 {
+		countQuery += " WHERE " + strings.Join(filters, " AND ")
+	}
+	if err := database.GetDB().QueryRow(countQuery, args...).Scan(&meta.Total); err != nil {
+		return nil, nil, err
 	}
 
-	response := APIResponse{
-		Success: true,
-		Data:    data,
+	sortColumn := listSortColumns[opts.Sort]
+	direction, comparison := "ASC", ">"
+	if opts.Desc {
+		direction, comparison = "DESC", "<"
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-}
+	if opts.After != nil {
+		cast := "::timestamptz"
+		if opts.Sort == "title" {
+			cast = "::text"
+		}
+		args = append(args, opts.After.Value, opts.After.ID)
+		filters = append(filters, fmt.Sprintf("(%s, id) %s ($%d%s, $%d::uuid)", sortColumn, comparison, len(args)-1, cast, len(args)))
+	}
 
-// queryAnalyticsData queries the database for analytics data, optionally filtered by type
-func queryAnalyticsData(dataType string) ([]models.AnalyticsData, error) {
-	var query string
-	var args []interface{}
+	columns := analyticsDataColumns
+	if !opts.includes("data") {
+		columns = analyticsSummaryColumns
+	}
 
-	if dataType == "" {
-		query = "SELECT id, title, description, data_type, data, created_at, updated_at FROM analytics_data ORDER BY created_at DESC"
-	} else {
-		query = "SELECT id, title, description, data_type, data, created_at, updated_at FROM analytics_data WHERE data_type = $1 ORDER BY created_at DESC"
-		args = append(args, dataType)
+	query := "SELECT " + columns + " FROM analytics_data"
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
 	}
+	// One extra row tells whether there is a next page
+	args = append(args, opts.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", sortColumn, direction, direction, len(args))
 
 	rows, err := database.GetDB().Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var results []models.AnalyticsData
+	results := []models.AnalyticsData{}
 	for rows.Next() {
-		var item models.AnalyticsData
-		var dataJSON []byte
-
-		err := rows.Scan(
-			&item.ID,
-			&item.Title,
-			&item.Description,
-			&item.DataType,
-			&dataJSON,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-		)
+		item, err := scanAnalyticsData(rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		// Parse the JSON data
-		err = json.Unmarshal(dataJSON, &item.Data)
-		if err != nil {
-			return nil, err
+		results = append(results, *item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+		last := results[len(results)-1]
+		cursor := &listCursor{Sort: opts.Sort, Desc: opts.Desc, ID: last.ID}
+		switch opts.Sort {
+		case "title":
+			cursor.Value = last.Title
+		case "updated_at":
+			cursor.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+		default:
+			cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		}
+		meta.NextCursor = cursor.encode()
+	}
+
+	return results, meta, nil
+}
+
+// projectAnalyticsData keeps only the selected fields of a dataset
+func projectAnalyticsData(item *models.AnalyticsData, fields []string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "id":
+			projected[field] = item.ID
+		case "title":
+			projected[field] = item.Title
+		case "description":
+			projected[field] = item.Description
+		case "data_type":
+			projected[field] = item.DataType
+		case "data":
+			projected[field] = item.Data
+		case "created_at":
+			projected[field] = item.CreatedAt
+		case "updated_at":
+			projected[field] = item.UpdatedAt
 		}
+	}
+	return projected
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-		results = append(results, item)
+// scanAnalyticsData scans one analytics_data row selected with analyticsDataColumns
+func scanAnalyticsData(row rowScanner) (*models.AnalyticsData, error) {
+	var item models.AnalyticsData
+	var dataJSON []byte
+
+	err := row.Scan(
+		&item.ID,
+		&item.Title,
+		&item.Description,
+		&item.DataType,
+		&dataJSON,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
+	// Parse the JSON data
+	if err := json.Unmarshal(dataJSON, &item.Data); err != nil {
 		return nil, err
 	}
 
-	return results, nil
+	return &item, nil
+}
+
+// writeJSON writes an APIResponse with the given status code
+func writeJSON(w http.ResponseWriter, status int, response APIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"fresherpaint/backend/stats"
+)
+
+// HistogramResponse is a histogram of one numeric series of a dataset
+type HistogramResponse struct {
+	DatasetID string `json:"dataset_id"`
+	Path      string `json:"path"`
+	Weights   string `json:"weights,omitempty"`
+	*stats.Histogram
+}
+
+// histogramHandler serves GET /api/analytics/{id}/histogram.
+//
+// path names the series ("measurements.invariant_mass") and weights an
+// optional parallel series of weights. bins is a bin count, "fd" for the
+// Freedman–Diaconis rule (the default) or a comma-separated list of edges;
+// width sets a fixed bin width instead, and min/max bound the range.
+func histogramHandler(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+	path := query.Get("path")
+	if path == "" {
+		writeError(w, apiError(CodeBadRequest, "Missing path parameter"))
+		return
+	}
+
+	opts, err := parseHistogramOptions(query)
+	if err != nil {
+		writeError(w, apiError(CodeBadRequest, "%v", err))
+		return
+	}
+
+	item, ok := loadDataset(w, id)
+	if !ok {
+		return
+	}
+
+	values, err := stats.Series(item.Data, path)
+	if err != nil {
+		writeError(w, apiError(CodeUnprocessable, "%v", err))
+		return
+	}
+
+	var weights []float64
+	if weightsPath := query.Get("weights"); weightsPath != "" {
+		weights, err = stats.Series(item.Data, weightsPath)
+		if err != nil {
+			writeError(w, apiError(CodeUnprocessable, "weights: %v", err))
+			return
+		}
+	}
+
+	histogram, err := stats.NewHistogram(values, weights, *opts)
+	if err != nil {
+		writeError(w, apiError(CodeUnprocessable, "%v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: HistogramResponse{
+		DatasetID: id,
+		Path:      path,
+		Weights:   query.Get("weights"),
+		Histogram: histogram,
+	}})
+}
+
+// parseHistogramOptions reads bins, width, min and max
+func parseHistogramOptions(query url.Values) (*stats.Options, error) {
+	opts := &stats.Options{}
+
+	switch bins := strings.TrimSpace(query.Get("bins")); {
+	case bins == "" || strings.EqualFold(bins, "fd"):
+	case strings.Contains(bins, ","):
+		for _, field := range strings.Split(bins, ",") {
+			edge, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bin edge %q", field)
+			}
+			opts.Edges = append(opts.Edges, edge)
+		}
+	default:
+		count, err := strconv.Atoi(bins)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("bins must be a positive count, \"fd\" or a list of edges")
+		}
+		opts.Bins = count
+	}
+
+	if width := query.Get("width"); width != "" {
+		value, err := strconv.ParseFloat(width, 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("width must be a positive number")
+		}
+		opts.Width = value
+	}
+
+	for name, target := range map[string]**float64{"min": &opts.Min, "max": &opts.Max} {
+		if raw := query.Get(name); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", name)
+			}
+			*target = &value
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/tabular"
+)
+
+const (
+	maxImportBytes     = 256 << 20 // whole multipart request
+	maxImportFieldSize = 64 << 10  // each non-file form field
+	maxImportRows      = 1000000
+	maxImportRowErrors = 100
+)
+
+// ImportResponse reports the outcome of a CSV/TSV import
+type ImportResponse struct {
+	Dataset      *models.AnalyticsData `json:"dataset,omitempty"`
+	RowsRead     int                   `json:"rows_read"`
+	RowsImported int                   `json:"rows_imported"`
+	RowsRejected int                   `json:"rows_rejected"`
+	Errors       []tabular.RowError    `json:"errors,omitempty"`
+}
+
+// importAnalyticsDataHandler serves POST /api/analytics/import.
+//
+// The multipart form carries title, description, data_type, mapping (JSON,
+// see tabular.Mapping), optional format ("csv" or "tsv") and skip_invalid
+// ("true" to import the valid rows when some rows fail), followed by the
+// file part. The file is converted row by row while it is uploaded, so every
+// other field must come before it.
+func importAnalyticsDataHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, apiError(CodeUnsupportedMedia, "Expected a multipart/form-data upload"))
+		return
+	}
+
+	fields := map[string]string{}
+	var input models.AnalyticsDataInput
+	var result *tabular.ImportResult
+	var mapping tabular.Mapping
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, unreadable("Invalid multipart body", err))
+			return
+		}
+
+		if part.FormName() != "file" {
+			value, err := io.ReadAll(io.LimitReader(part, maxImportFieldSize+1))
+			if err != nil || len(value) > maxImportFieldSize {
+				writeError(w, apiError(CodeInvalidBody, "Form field %q is too large or unreadable", part.FormName()))
+				return
+			}
+			fields[part.FormName()] = string(value)
+			continue
+		}
+
+		if result != nil {
+			writeError(w, apiError(CodeBadRequest, "Only one file can be imported at a time"))
+			return
+		}
+
+		// Reject bad metadata before spending time on the upload
+		input = models.AnalyticsDataInput{
+			Title:       fields["title"],
+			Description: fields["description"],
+			DataType:    models.AnalyticsType(fields["data_type"]),
+			Data:        json.RawMessage("{}"), // replaced by the converted rows
+		}
+		if err := input.Validate(datasetTypes.Has); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := parseImportMapping(fields["mapping"], &mapping); err != nil {
+			writeError(w, apiError(CodeBadRequest, "%v", err))
+			return
+		}
+
+		comma, err := importDelimiter(fields["format"], part.FileName(), part.Header.Get("Content-Type"))
+		if err != nil {
+			writeError(w, apiError(CodeBadRequest, "%v", err))
+			return
+		}
+
+		result, err = tabular.Read(part, &mapping, tabular.Options{
+			Comma:     comma,
+			MaxRows:   maxImportRows,
+			MaxErrors: maxImportRowErrors,
+		})
+		if err != nil {
+			writeError(w, unreadable("Failed to read file", err))
+			return
+		}
+	}
+
+	if result == nil {
+		writeError(w, apiError(CodeBadRequest, "Missing file part"))
+		return
+	}
+
+	summary := ImportResponse{
+		RowsRead:     result.RowsRead,
+		RowsImported: len(result.Records),
+		RowsRejected: result.ErrorCount,
+		Errors:       result.Errors,
+	}
+
+	skipInvalid, _ := strconv.ParseBool(fields["skip_invalid"])
+	if result.ErrorCount > 0 && !skipInvalid {
+		writeError(w, &APIError{
+			Code:    CodeValidation,
+			Message: fmt.Sprintf("%d of %d rows could not be converted; nothing was imported", result.ErrorCount, result.RowsRead),
+			Details: summary,
+		})
+		return
+	}
+
+	if len(result.Records) == 0 {
+		writeError(w, &APIError{Code: CodeUnprocessable, Message: "File contains no importable rows", Details: summary})
+		return
+	}
+
+	data, err := json.Marshal(mapping.Payload(result.Records))
+	if err != nil {
+		writeError(w, internalError("Failed to encode payload", err))
+		return
+	}
+
+	if errs := validateDatasetPayload(input.DataType, data); len(errs) > 0 {
+		writeError(w, schemaErrors(errs))
+		return
+	}
+
+	row := database.GetDB().QueryRow(`
+		INSERT INTO analytics_data (title, description, data_type, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING `+analyticsDataColumns,
+		input.Title, input.Description, input.DataType, data,
+	)
+
+	summary.Dataset, err = scanAnalyticsData(row)
+	if err != nil {
+		writeError(w, datasetStoreError(err, &input.DataType, "Failed to store dataset"))
+		return
+	}
+
+	w.Header().Set("Location", "/api/analytics/"+summary.Dataset.ID)
+	writeJSON(w, http.StatusCreated, APIResponse{Success: true, Data: summary})
+}
+
+// parseImportMapping decodes and validates the mapping form field
+func parseImportMapping(raw string, mapping *tabular.Mapping) error {
+	if strings.TrimSpace(raw) == "" {
+		return fmt.Errorf("the mapping field must be sent before the file")
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(mapping); err != nil {
+		return fmt.Errorf("invalid mapping: %w", err)
+	}
+
+	if err := mapping.Validate(); err != nil {
+		return fmt.Errorf("invalid mapping: %w", err)
+	}
+	return nil
+}
+
+// importDelimiter picks the field delimiter from the explicit format, or
+// from the file name and content type when no format is given
+func importDelimiter(format, fileName, contentType string) (rune, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return ',', nil
+	case "tsv":
+		return '\t', nil
+	case "":
+	default:
+		return 0, fmt.Errorf("unknown format %q (expected csv or tsv)", format)
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if ext == ".tsv" || ext == ".tab" || strings.HasPrefix(contentType, "text/tab-separated-values") {
+		return '\t', nil
+	}
+	return ',', nil
+}
@@ -0,0 +1,148 @@
+// Package jwtkeys manages the keys that sign and verify access tokens. Keys
+// are persisted in the signing_keys table so every replica and restart
+// shares them, are identified by the kid header of each token, and are
+// rotated on a schedule: a retired key keeps verifying tokens for an overlap
+// window after a newer key takes over signing. Public keys of the asymmetric
+// algorithms are published as a JSON Web Key Set.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	HS256 = "HS256" // HMAC-SHA256 with a shared secret; never published
+	RS256 = "RS256" // RSA PKCS#1 v1.5 with SHA-256
+	EdDSA = "EdDSA" // Ed25519
+)
+
+// Algorithms lists the supported algorithms
+var Algorithms = []string{HS256, RS256, EdDSA}
+
+const (
+	rsaKeyBits   = 2048
+	hmacKeyBytes = 32
+)
+
+// Key is a signing key and its lifecycle
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	// RetiredAt is when the key stopped signing, ExpiresAt when it stops
+	// verifying; both are nil for the current key
+	RetiredAt *time.Time
+	ExpiresAt *time.Time
+
+	secret  []byte        // HS256
+	private crypto.Signer // RS256 and EdDSA
+}
+
+// Method is the JWT signing method of the key
+func (k *Key) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// Asymmetric reports whether the key has a public half that may be
+// published
+func (k *Key) Asymmetric() bool {
+	return k.private != nil
+}
+
+func (k *Key) signingKey() interface{} {
+	if k.private != nil {
+		return k.private
+	}
+	return k.secret
+}
+
+func (k *Key) verificationKey() interface{} {
+	if k.private != nil {
+		return k.private.Public()
+	}
+	return k.secret
+}
+
+// usable reports whether the key still verifies tokens at time now
+func (k *Key) usable(now time.Time) bool {
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// generateKey creates a key with a random kid
+func generateKey(algorithm string) (*Key, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	key := &Key{ID: hex.EncodeToString(id), Algorithm: algorithm, CreatedAt: time.Now()}
+
+	switch algorithm {
+	case HS256:
+		key.secret = make([]byte, hmacKeyBytes)
+		if _, err := rand.Read(key.secret); err != nil {
+			return nil, err
+		}
+	case RS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+	case EdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	return key, nil
+}
+
+// marshalPrivate encodes the key material for storage: the raw secret for
+// HS256 and PKCS #8 DER otherwise
+func (k *Key) marshalPrivate() ([]byte, error) {
+	if k.private == nil {
+		return k.secret, nil
+	}
+	return x509.MarshalPKCS8PrivateKey(k.private)
+}
+
+// unmarshalPrivate is the inverse of marshalPrivate
+func (k *Key) unmarshalPrivate(der []byte) error {
+	if k.Algorithm == HS256 {
+		k.secret = der
+		return nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", k.ID, err)
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.Algorithm != RS256 {
+			return fmt.Errorf("key %s: RSA key stored for %s", k.ID, k.Algorithm)
+		}
+		k.private = private
+	case ed25519.PrivateKey:
+		if k.Algorithm != EdDSA {
+			return fmt.Errorf("key %s: Ed25519 key stored for %s", k.ID, k.Algorithm)
+		}
+		k.private = private
+	default:
+		return fmt.Errorf("key %s: unsupported key type %T", k.ID, parsed)
+	}
+	return nil
+}
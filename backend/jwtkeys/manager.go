@@ -0,0 +1,327 @@
+package jwtkeys
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// rotationLockKey is the pg_advisory_xact_lock key that stops two replicas
+// rotating at the same time
+const rotationLockKey = 727364002
+
+// reloadCooldown limits how often an unknown kid triggers a reload
+const reloadCooldown = 10 * time.Second
+
+// Config configures a Manager
+type Config struct {
+	// Algorithm signs new tokens: HS256, RS256 or EdDSA
+	Algorithm string
+	// Secret, when set with HS256, is a fixed shared key that is never
+	// rotated or stored
+	Secret string
+	// RotateEvery is the age at which the signing key is replaced
+	RotateEvery time.Duration
+	// Overlap is how long a retired key keeps verifying tokens; it must be
+	// at least the lifetime of a token
+	Overlap time.Duration
+}
+
+// Validate checks the configuration
+func (c *Config) Validate() error {
+	known := false
+	for _, algorithm := range Algorithms {
+		known = known || c.Algorithm == algorithm
+	}
+	if !known {
+		return fmt.Errorf("unsupported signing algorithm %q (use HS256, RS256 or EdDSA)", c.Algorithm)
+	}
+	if c.Secret != "" && c.Algorithm != HS256 {
+		return fmt.Errorf("a fixed secret can only be used with HS256")
+	}
+	if c.RotateEvery <= 0 || c.Overlap <= 0 {
+		return fmt.Errorf("the rotation interval and overlap must be positive")
+	}
+	return nil
+}
+
+// Manager signs tokens with the current key and verifies them with any key
+// that has not expired
+type Manager struct {
+	db     *sql.DB
+	config Config
+
+	mu       sync.RWMutex
+	keys     map[string]*Key
+	current  *Key
+	loadedAt time.Time
+}
+
+// NewManager returns a manager for the keys in the signing_keys table. Call
+// Load before signing.
+func NewManager(db *sql.DB, config Config) (*Manager, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Manager{db: db, config: config, keys: map[string]*Key{}}, nil
+}
+
+// Load reads the stored keys and creates the first signing key if there is
+// no usable one for the configured algorithm
+func (m *Manager) Load() error {
+	if err := m.reload(); err != nil {
+		return err
+	}
+	_, err := m.RotateIfDue()
+	return err
+}
+
+// reload replaces the cached keys with the usable rows of signing_keys
+func (m *Manager) reload() error {
+	rows, err := m.db.Query(`
+		SELECT kid, algorithm, private_key, created_at, retired_at, expires_at
+		FROM signing_keys
+		WHERE expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at`)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := map[string]*Key{}
+	var current *Key
+	for rows.Next() {
+		var key Key
+		var der []byte
+		var retiredAt, expiresAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Algorithm, &der, &key.CreatedAt, &retiredAt, &expiresAt); err != nil {
+			return err
+		}
+		if err := key.unmarshalPrivate(der); err != nil {
+			return err
+		}
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		}
+		if expiresAt.Valid {
+			key.ExpiresAt = &expiresAt.Time
+		}
+		keys[key.ID] = &key
+		if key.RetiredAt == nil && key.Algorithm == m.config.Algorithm {
+			current = &key
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if static := m.staticKey(); static != nil {
+		keys[static.ID] = static
+		current = static
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.current = current
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// staticKey is the fixed HS256 key from Config.Secret, if any. Its kid is
+// derived from the secret so every replica agrees on it.
+func (m *Manager) staticKey() *Key {
+	if m.config.Secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte("kid:" + m.config.Secret))
+	return &Key{ID: "static-" + hex.EncodeToString(sum[:6]), Algorithm: HS256, secret: []byte(m.config.Secret)}
+}
+
+// RotateIfDue rotates when there is no signing key for the configured
+// algorithm or the current one is older than the rotation interval. It
+// reports whether a new key was created.
+func (m *Manager) RotateIfDue() (bool, error) {
+	if m.config.Secret != "" {
+		return false, nil
+	}
+
+	m.mu.RLock()
+	current := m.current
+	m.mu.RUnlock()
+	if current != nil && time.Since(current.CreatedAt) < m.config.RotateEvery {
+		return false, nil
+	}
+	return m.rotate(false)
+}
+
+// Rotate replaces the signing key now, whatever its age
+func (m *Manager) Rotate() error {
+	if m.config.Secret != "" {
+		return errors.New("a fixed JWT secret is configured; unset it to use rotating keys")
+	}
+	_, err := m.rotate(true)
+	return err
+}
+
+// rotate creates a new signing key and retires the others. Replicas take an
+// advisory lock and re-check inside it, so only one of them rotates when a
+// key falls due.
+func (m *Manager) rotate(force bool) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", rotationLockKey); err != nil {
+		return false, fmt.Errorf("failed to lock signing keys: %w", err)
+	}
+
+	if !force {
+		var newest sql.NullTime
+		err := tx.QueryRow(
+			"SELECT MAX(created_at) FROM signing_keys WHERE retired_at IS NULL AND algorithm = $1",
+			m.config.Algorithm,
+		).Scan(&newest)
+		if err != nil {
+			return false, err
+		}
+		if newest.Valid && time.Since(newest.Time) < m.config.RotateEvery {
+			// Another replica rotated first
+			return false, m.reloadAfter(tx)
+		}
+	}
+
+	key, err := generateKey(m.config.Algorithm)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	der, err := key.marshalPrivate()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE signing_keys SET retired_at = NOW(), expires_at = NOW() + make_interval(secs => $1) WHERE retired_at IS NULL",
+		m.config.Overlap.Seconds(),
+	); err != nil {
+		return false, fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO signing_keys (kid, algorithm, private_key, created_at) VALUES ($1, $2, $3, $4)",
+		key.ID, key.Algorithm, der, key.CreatedAt,
+	); err != nil {
+		return false, fmt.Errorf("failed to store signing key: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM signing_keys WHERE expires_at <= NOW()"); err != nil {
+		return false, err
+	}
+
+	if err := m.reloadAfter(tx); err != nil {
+		return false, err
+	}
+	slog.Info("Rotated JWT signing key", "algorithm", key.Algorithm, "kid", key.ID)
+	return true, nil
+}
+
+// reloadAfter commits tx and reloads the keys
+func (m *Manager) reloadAfter(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return m.reload()
+}
+
+// Run reloads the keys and rotates when due, every interval
+func (m *Manager) Run(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := m.reload(); err != nil {
+			slog.Warn("Failed to reload signing keys", "error", err)
+			continue
+		}
+		if _, err := m.RotateIfDue(); err != nil {
+			slog.Warn("Failed to rotate JWT signing key", "error", err)
+		}
+	}
+}
+
+// Keys returns the usable keys, newest first
+func (m *Manager) Keys() []*Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]*Key, 0, len(m.keys))
+	for _, key := range m.keys {
+		if key.usable(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys
+}
+
+// Sign signs claims with the current key and sets the kid header
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	key := m.current
+	m.mu.RUnlock()
+	if key == nil {
+		return "", errors.New("no signing key is loaded")
+	}
+
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signingKey())
+}
+
+// Keyfunc finds the verification key named by a token's kid header. The
+// token's alg must match the key's algorithm, so an RS256 public key can
+// never be used as an HMAC secret.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	key := m.lookup(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("token algorithm %s does not match key %s", token.Method.Alg(), kid)
+	}
+	return key.verificationKey(), nil
+}
+
+// lookup finds a usable key, reloading once if the kid is unknown so keys
+// created by another replica are found before the next scheduled reload
+func (m *Manager) lookup(kid string) *Key {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	stale := time.Since(m.loadedAt) > reloadCooldown
+	m.mu.RUnlock()
+
+	if !ok && stale {
+		if err := m.reload(); err != nil {
+			slog.Warn("Failed to reload signing keys", "error", err)
+			return nil
+		}
+		m.mu.RLock()
+		key, ok = m.keys[kid]
+		m.mu.RUnlock()
+	}
+	if !ok || !key.usable(time.Now()) {
+		return nil
+	}
+	return key
+}
@@ -0,0 +1,197 @@
+package jwtkeys
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testManager returns a manager holding one key per algorithm, signing with
+// the given one. loadedAt is current so unknown kids do not reload from the
+// (absent) database.
+func testManager(t *testing.T, signWith string) (*Manager, map[string]*Key) {
+	t.Helper()
+
+	m := &Manager{config: Config{Algorithm: signWith}, keys: map[string]*Key{}, loadedAt: time.Now()}
+	byAlgorithm := map[string]*Key{}
+	for _, algorithm := range Algorithms {
+		key, err := generateKey(algorithm)
+		if err != nil {
+			t.Fatalf("generateKey(%s): %v", algorithm, err)
+		}
+		m.keys[key.ID] = key
+		byAlgorithm[algorithm] = key
+	}
+	m.current = byAlgorithm[signWith]
+	return m, byAlgorithm
+}
+
+func parse(m *Manager, token string) error {
+	_, err := jwt.Parse(token, m.Keyfunc, jwt.WithValidMethods(Algorithms))
+	return err
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			m, keys := testManager(t, algorithm)
+			token, err := m.Sign(claims())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %v", err)
+			}
+			if parsed.Header["kid"] != keys[algorithm].ID || parsed.Header["alg"] != algorithm {
+				t.Errorf("header = %v, want kid %s and alg %s", parsed.Header, keys[algorithm].ID, algorithm)
+			}
+			if err := parse(m, token); err != nil {
+				t.Errorf("token does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyfuncRejects(t *testing.T) {
+	m, keys := testManager(t, RS256)
+	rsaKey, edKey, hmacKey := keys[RS256], keys[EdDSA], keys[HS256]
+
+	// sign builds a token with an arbitrary header, signed by any method
+	sign := func(method jwt.SigningMethod, kid interface{}, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims())
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(rsaKey.private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := time.Now().Add(-time.Second)
+	retired, err := generateKey(EdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired.ExpiresAt = &expired
+	m.keys[retired.ID] = retired
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"HS256 signed with the RSA public key under its kid", sign(jwt.SigningMethodHS256, rsaKey.ID, rsaPublicDER), "does not match"},
+		{"RS256 token naming the Ed25519 kid", sign(jwt.SigningMethodRS256, edKey.ID, rsaKey.private), "does not match"},
+		{"EdDSA token naming the HMAC kid", sign(jwt.SigningMethodEdDSA, hmacKey.ID, edKey.private), "does not match"},
+		{"HS256 token naming the RSA kid with the HMAC secret", sign(jwt.SigningMethodHS256, rsaKey.ID, hmacKey.secret), "does not match"},
+		{"unsigned token", sign(jwt.SigningMethodNone, rsaKey.ID, jwt.UnsafeAllowNoneSignatureType), "signing method none is invalid"},
+		{"HS384 is not accepted", sign(jwt.SigningMethodHS384, hmacKey.ID, hmacKey.secret), "signing method HS384 is invalid"},
+		{"no kid", sign(jwt.SigningMethodRS256, nil, rsaKey.private), "no kid"},
+		{"kid is not a string", sign(jwt.SigningMethodRS256, 7, rsaKey.private), "no kid"},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "missing", rsaKey.private), "unknown signing key"},
+		{"expired key", sign(jwt.SigningMethodEdDSA, retired.ID, retired.private), "unknown signing key"},
+		{"signed by another key with the right kid", sign(jwt.SigningMethodEdDSA, edKey.ID, retired.private), "signature is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parse(m, tt.token)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaticKey(t *testing.T) {
+	a := (&Manager{config: Config{Algorithm: HS256, Secret: "shared"}}).staticKey()
+	b := (&Manager{config: Config{Algorithm: HS256, Secret: "shared"}}).staticKey()
+	c := (&Manager{config: Config{Algorithm: HS256, Secret: "other"}}).staticKey()
+	if a.ID != b.ID || a.ID == c.ID || !strings.HasPrefix(a.ID, "static-") {
+		t.Errorf("static kids %q, %q, %q: want equal for equal secrets only", a.ID, b.ID, c.ID)
+	}
+	if (&Manager{config: Config{Algorithm: HS256}}).staticKey() != nil {
+		t.Error("a static key without a secret")
+	}
+}
+
+func TestJWKSPublishesOnlyAsymmetricKeys(t *testing.T) {
+	m, keys := testManager(t, EdDSA)
+	set := m.JWKS()
+
+	published := map[string]JWK{}
+	for _, jwk := range set.Keys {
+		published[jwk.KeyID] = jwk
+	}
+	if _, ok := published[keys[HS256].ID]; ok {
+		t.Error("the HS256 secret was published")
+	}
+	if jwk := published[keys[RS256].ID]; jwk.KeyType != "RSA" || jwk.N == "" || jwk.E != "AQAB" {
+		t.Errorf("RSA key = %+v", jwk)
+	}
+	if jwk := published[keys[EdDSA].ID]; jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.X == "" {
+		t.Errorf("Ed25519 key = %+v", jwk)
+	}
+}
+
+func TestUnmarshalPrivateChecksAlgorithm(t *testing.T) {
+	tests := []struct {
+		stored, as string
+		wantErr    bool
+	}{
+		{RS256, RS256, false},
+		{EdDSA, EdDSA, false},
+		{RS256, EdDSA, true},
+		{EdDSA, RS256, true},
+	}
+
+	for _, tt := range tests {
+		key, err := generateKey(tt.stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := key.marshalPrivate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := &Key{ID: key.ID, Algorithm: tt.as}
+		if err := loaded.unmarshalPrivate(der); (err != nil) != tt.wantErr {
+			t.Errorf("%s key loaded as %s: error %v, want error %t", tt.stored, tt.as, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"rotating EdDSA", Config{Algorithm: EdDSA, RotateEvery: time.Hour, Overlap: time.Minute}, false},
+		{"fixed HS256 secret", Config{Algorithm: HS256, Secret: "s", RotateEvery: time.Hour, Overlap: time.Minute}, false},
+		{"unknown algorithm", Config{Algorithm: "ES256", RotateEvery: time.Hour, Overlap: time.Minute}, true},
+		{"secret with RS256", Config{Algorithm: RS256, Secret: "s", RotateEvery: time.Hour, Overlap: time.Minute}, true},
+		{"no overlap", Config{Algorithm: RS256, RotateEvery: time.Hour}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// listSortColumns maps the sort keys accepted by the list endpoints to the
+// SQL expression they order by; id breaks ties so the order is total
+var listSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "COALESCE(updated_at, created_at)",
+	"title":      "title",
+}
+
+// listFields are the dataset fields that can be selected with fields=
+var listFields = []string{"id", "title", "description", "data_type", "data", "created_at", "updated_at"}
+
+// ListOptions controls paging, ordering and projection of a dataset list
+type ListOptions struct {
+	DataType string
+	Sort     string
+	Desc     bool
+	Limit    int
+	After    *listCursor
+	Fields   []string // nil selects every field
+}
+
+// ListMeta describes the page returned by a list endpoint
+type ListMeta struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// listCursor is the position after the last row of a page. It records the
+// ordering it was issued for so it cannot be replayed against another one.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func (c *listCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeListCursor(token string) (*listCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil || !uuidPattern.MatchString(c.ID) {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if _, ok := listSortColumns[c.Sort]; !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if c.Sort != "title" {
+		if _, err := time.Parse(time.RFC3339Nano, c.Value); err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+	}
+	return &c, nil
+}
+
+// parseListOptions reads limit, cursor, sort, order and fields from the
+// query string. Timestamps sort newest first and titles A to Z unless
+// order says otherwise.
+func parseListOptions(query url.Values) (*ListOptions, error) {
+	opts := &ListOptions{Sort: "created_at", Limit: defaultListLimit}
+
+	if sort := query.Get("sort"); sort != "" {
+		if _, ok := listSortColumns[sort]; !ok {
+			return nil, fmt.Errorf("sort must be one of title, created_at, updated_at")
+		}
+		opts.Sort = sort
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "":
+		opts.Desc = opts.Sort != "title"
+	case "asc":
+		opts.Desc = false
+	case "desc":
+		opts.Desc = true
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxListLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		opts.Limit = n
+	}
+
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := decodeListCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != opts.Sort || cursor.Desc != opts.Desc {
+			return nil, fmt.Errorf("cursor was issued for a different sort order")
+		}
+		opts.After = cursor
+	}
+
+	if fields := query.Get("fields"); fields != "" {
+		opts.Fields = []string{"id"} // always returned so rows stay addressable
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if !isListField(field) {
+				return nil, fmt.Errorf("unknown field %q (expected %s)", field, strings.Join(listFields, ", "))
+			}
+			if !opts.includes(field) {
+				opts.Fields = append(opts.Fields, field)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+func isListField(name string) bool {
+	for _, field := range listFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// includes reports whether the projection selects the field
+func (o *ListOptions) includes(field string) bool {
+	if o.Fields == nil {
+		return true
+	}
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// healthCheckPath is the route whose successful requests are sampled out of
+// the access log
+const healthCheckPath = "/health"
+
+// accessLog decides which requests are logged
+type accessLog struct {
+	// healthSample logs one in every healthSample successful health checks;
+	// 0 logs none
+	healthSample int64
+	healthChecks atomic.Int64
+}
+
+var accessLogs = &accessLog{}
+
+// InitializeLogging installs the default slog logger. The standard log
+// package writes through it too.
+func InitializeLogging(config *Config) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+		return fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(config.LogFormat) {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("LOG_FORMAT: unknown format %q (use text or json)", config.LogFormat)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	accessLogs = &accessLog{healthSample: config.HealthLogSample}
+	return nil
+}
+
+// fatal logs an error that stops the server and exits
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
+
+// statusRecorder captures the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// accessLogMiddleware logs every request once it has been served, with its
+// request ID and the authenticated user, if any. Place it inside
+// requestIDMiddleware.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if !accessLogs.wanted(r, rec.status) {
+			return
+		}
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		info := requestInfoFrom(r)
+		slog.LogAttrs(r.Context(), level, "Request",
+			slog.String("request_id", info.ID),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes", rec.bytes),
+			slog.String("user_id", info.UserID),
+			slog.String("ip", clientIP(r)),
+		)
+	})
+}
+
+// wanted reports whether a request should be logged. Failed health checks
+// are always logged; successful ones are sampled.
+func (a *accessLog) wanted(r *http.Request, status int) bool {
+	if r.URL.Path != healthCheckPath || status >= http.StatusBadRequest {
+		return true
+	}
+	if a.healthSample <= 0 {
+		return false
+	}
+	return (a.healthChecks.Add(1)-1)%a.healthSample == 0
+}
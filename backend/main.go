@@ -1,15 +1,14 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
-	"path/filepath"
+	"os"
 	"time"
 
 	"fresherpaint/backend/db"
+	"fresherpaint/backend/router"
 )
 
 var database *db.Database
@@ -18,175 +17,223 @@ func main() {
 	// Load configuration
 	config := LoadConfig()
 
+	// Configure log level and format before anything else logs
+	if err := InitializeLogging(config); err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+
+	// Subcommands (e.g. `main seed --reset`) run against the database and exit
+	if len(os.Args) > 1 {
+		if err := runCommand(config, os.Args[1], os.Args[2:]); err != nil {
+			fatal("Command failed", fmt.Errorf("%s: %w", os.Args[1], err))
+		}
+		return
+	}
+
 	// Initialize authentication system
 	if err := InitializeAuth(config); err != nil {
-		log.Fatalf("Failed to initialize authentication: %v", err)
+		fatal("Failed to initialize authentication", err)
 	}
 
-	// Initialize database connection
-	dbConfig := &db.Config{
-		DBHost:     config.DBHost,
-		DBPort:     config.DBPort,
-		DBUser:     config.DBUser,
-		DBPassword: config.DBPassword,
-		DBName:     config.DBName,
+	// Build the CORS origin allowlist
+	if err := InitializeCORS(config); err != nil {
+		fatal("Invalid CORS configuration", err)
 	}
 
-	var err error
-	database, err = db.NewDatabase(dbConfig)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// Apply the /metrics settings
+	if err := InitializeMetrics(config); err != nil {
+		fatal("Invalid metrics configuration", err)
+	}
+
+	// Build the request rate limiters
+	if err := InitializeRateLimits(config); err != nil {
+		fatal("Invalid rate limit configuration", err)
 	}
-	defer database.Close()
 
-	log.Printf("Connected to database successfully")
+	// Initialize database connection
+	if err := connectDatabase(config); err != nil {
+		fatal("Failed to connect to database", err)
+	}
+	defer database.Close()
 
 	// Run database migrations
 	if err := runMigrations(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		fatal("Failed to run migrations", err)
 	}
 
-	// Clean up and regenerate sample data
-	if err := cleanupAndRegenerateData(); err != nil {
-		log.Fatalf("Failed to cleanup and regenerate data: %v", err)
+	// Load the dataset type registry
+	if err := datasetTypes.Load(); err != nil {
+		fatal("Failed to load dataset types", err)
 	}
 
-	// Setup routes
-	// Public routes
-	http.HandleFunc("/health", healthCheckHandler) // No CORS middleware for health checks
-	http.HandleFunc("/api/auth/login", corsMiddleware(loginHandler))
-
-	// Protected routes (require authentication)
-	http.HandleFunc("/api/auth/verify", corsMiddleware(authMiddleware(verifyTokenHandler)))
-	http.HandleFunc("/api/analytics", corsMiddleware(authMiddleware(getAnalyticsDataHandler)))
-	http.HandleFunc("/api/analytics/type", corsMiddleware(authMiddleware(getAnalyticsDataByTypeHandler)))
-
-	// Start the server
-	serverAddr := fmt.Sprintf("0.0.0.0:%s", config.ServerPort)
-	log.Printf("Server starting on %s\n", serverAddr)
-	log.Printf("Health check available at: http://%s/health", serverAddr)
-	log.Printf("Available endpoints:")
-	log.Printf("  GET /health - Health check")
-	log.Printf("  POST /api/auth/login - User authentication")
-	log.Printf("  POST /api/auth/verify - Verify JWT token (protected)")
-	log.Printf("  GET /api/analytics - Get all analytics data (protected)")
-	log.Printf("  GET /api/analytics/type?type=physics|computer_science - Get filtered data (protected)")
-
-	if err := http.ListenAndServe(serverAddr, nil); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// Create the first admin account if there are no users yet
+	if err := ensureAdminUser(config); err != nil {
+		fatal("Failed to create admin user", err)
 	}
-}
 
-func runMigrations() error {
-	log.Printf("Running database migrations...")
+	// Load the JWT signing keys and rotate them on schedule
+	if err := initSigningKeys(config); err != nil {
+		fatal("Failed to load JWT signing keys", err)
+	}
+	go signingKeys.Run(time.Minute)
 
-	// Read the migration file
-	migrationPath := filepath.Join("migrations", "001_initial_schema.sql")
-	migrationSQL, err := ioutil.ReadFile(migrationPath)
-	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
+	// Load revoked access tokens, then keep the list in sync with other replicas
+	if err := revocations.Sync(); err != nil {
+		fatal("Failed to load revoked tokens", err)
 	}
+	go revocations.Run(15 * time.Second)
 
-	// Execute the migration
-	_, err = database.GetDB().Exec(string(migrationSQL))
-	if err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
+	// Insert missing built-in datasets according to SEED_MODE
+	if err := seedOnStartup(config); err != nil {
+		fatal("Failed to seed datasets", err)
 	}
 
-	log.Printf("Database migrations completed successfully")
-	return nil
-}
+	// Setup routes. Every route runs the CORS middleware; groups add
+	// authentication and rate limits.
+	routes := router.New()
+	routes.Use(corsMiddleware)
+	routes.MethodNotAllowed = func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, apiError(CodeMethodNotAllowed, "Method not allowed"))
+	}
+	routes.NotFound = func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, apiError(CodeNotFound, "Not found"))
+	}
 
-func cleanupAndRegenerateData() error {
-	log.Printf("Cleaning up existing sample data...")
+	// Public routes
+	routes.HandleFunc("GET /health", healthCheckHandler)
+	routes.HandleFunc("POST /api/auth/logout", logoutHandler)
+	routes.HandleFunc("GET /.well-known/jwks.json", jwksHandler)
+	routes.HandleFunc("GET /metrics", metricsHandler)
 
-	// Delete all existing sample data
-	_, err := database.GetDB().Exec("DELETE FROM analytics_data")
-	if err != nil {
-		return fmt.Errorf("failed to cleanup existing data: %w", err)
-	}
+	login := routes.Group(rateLimitMiddleware(rateLimits.Auth))
+	login.HandleFunc("POST /api/auth/login", loginHandler)
+	login.HandleFunc("POST /api/auth/refresh", refreshHandler)
 
-	log.Printf("Generating real-world datasets...")
+	// Protected routes (require authentication)
+	routes.Group(authMiddleware(anyUser)).HandleFunc("POST /api/auth/verify", verifyTokenHandler)
+
+	protected := routes.Group(authMiddleware(editorWrites))
+	api := protected.Group(rateLimitMiddleware(rateLimits.API))
+	api.HandleFunc("GET /api/analytics", getAnalyticsDataHandler)
+	api.HandleFunc("POST /api/analytics", createAnalyticsDataHandler)
+	api.HandleFunc("GET /api/analytics/type", getAnalyticsDataByTypeHandler)
+	api.HandleFunc("GET /api/analytics/{id}", datasetHandler(getAnalyticsDataByIDHandler))
+	api.HandleFunc("PUT /api/analytics/{id}", datasetHandler(replaceAnalyticsDataHandler))
+	api.HandleFunc("PATCH /api/analytics/{id}", datasetHandler(patchAnalyticsDataHandler))
+	api.HandleFunc("DELETE /api/analytics/{id}", datasetHandler(deleteAnalyticsDataHandler))
+	api.HandleFunc("GET /api/analytics/{id}/export", datasetHandler(exportAnalyticsDataHandler))
+	api.HandleFunc("GET /api/analytics/{id}/histogram", datasetHandler(histogramHandler))
+	api.HandleFunc("GET /api/analytics/{id}/fits", datasetHandler(listFitsHandler))
+	api.HandleFunc("POST /api/analytics/{id}/fits", datasetHandler(createFitHandler))
+	api.HandleFunc("GET /api/analytics/{id}/chsh", datasetHandler(chshHandler))
+	api.HandleFunc("GET /api/analytics/{id}/complexity", datasetHandler(complexityHandler))
+	api.HandleFunc("GET /api/generators", listGeneratorsHandler)
+	api.HandleFunc("GET /api/generators/{name}", getGeneratorHandler)
+	api.HandleFunc("GET /api/dataset-types", listDatasetTypesHandler)
+	api.HandleFunc("GET /api/schemas", listSchemasHandler)
+	api.HandleFunc("GET /api/schemas/{name}", getSchemaHandler)
+
+	heavy := protected.Group(rateLimitMiddleware(rateLimits.Heavy))
+	heavy.HandleFunc("POST /api/analytics/import", importAnalyticsDataHandler)
+	heavy.HandleFunc("POST /api/generators/{name}/run", runGeneratorHandler)
+
+	admin := routes.Group(authMiddleware(adminOnly), rateLimitMiddleware(rateLimits.API))
+	admin.HandleFunc("POST /api/admin/dataset-types", createDatasetTypeHandler)
+	admin.HandleFunc("PUT /api/admin/dataset-types/{name}", updateDatasetTypeHandler)
+	admin.HandleFunc("DELETE /api/admin/dataset-types/{name}", deleteDatasetTypeHandler)
+	admin.HandleFunc("GET /api/admin/users", listUsersHandler)
+	admin.HandleFunc("POST /api/admin/users", createUserHandler)
+	admin.HandleFunc("GET /api/admin/users/{id}", getUserHandler)
+	admin.HandleFunc("PATCH /api/admin/users/{id}", updateUserHandler)
+	admin.HandleFunc("DELETE /api/admin/users/{id}", deleteUserHandler)
 
-	// Generate real physics datasets
-	physicsDatasets, err := generateRealPhysicsData()
-	if err != nil {
-		return fmt.Errorf("failed to generate physics data: %w", err)
+	// Start the server
+	serverAddr := fmt.Sprintf("0.0.0.0:%s", config.ServerPort)
+	slog.Info("Server starting", "addr", serverAddr, "health_check", "http://"+serverAddr+healthCheckPath)
+	slog.Debug("Endpoint", "route", "GET /health", "description", "Health check")
+	slog.Debug("Endpoint", "route", "GET /metrics", "description", "Prometheus metrics (METRICS_TOKEN if set)")
+	slog.Debug("Endpoint", "route", "POST /api/auth/login", "description", "Log in with username and password")
+	slog.Debug("Endpoint", "route", "POST /api/auth/refresh", "description", "Exchange a refresh token for new tokens")
+	slog.Debug("Endpoint", "route", "POST /api/auth/logout", "description", "Revoke the access token and refresh token")
+	slog.Debug("Endpoint", "route", "GET /.well-known/jwks.json", "description", "Public keys that verify access tokens")
+	slog.Debug("Endpoint", "route", "POST /api/auth/verify", "description", "Verify JWT token (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/analytics?limit=&cursor=&sort=&order=&fields=", "description", "List analytics data, one page at a time (protected)")
+	slog.Debug("Endpoint", "route", "POST /api/analytics", "description", "Create a dataset (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/analytics/type?type={name}", "description", "Get data filtered by dataset type (protected)")
+	slog.Debug("Endpoint", "route", "POST /api/analytics/import", "description", "Import a dataset from CSV/TSV (protected)")
+	slog.Debug("Endpoint", "route", "GET|PUT|PATCH|DELETE /api/analytics/{id}", "description", "Read, replace, update or delete a dataset (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/analytics/{id}/export?format=csv|jsonl|parquet|arrow", "description", "Export a dataset table (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/analytics/{id}/histogram?path=&bins=&weights=", "description", "Histogram a numeric series (protected)")
+	slog.Debug("Endpoint", "route", "GET|POST /api/analytics/{id}/fits", "description", "List or run signal-plus-background fits (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/analytics/{id}/chsh", "description", "CHSH Bell parameter from quantum_measurements (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/analytics/{id}/complexity?radix=", "description", "Fit algorithm runtimes against complexity classes (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/generators", "description", "List dataset generators with their parameter schemas (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/generators/{name}", "description", "Get one dataset generator (protected)")
+	slog.Debug("Endpoint", "route", "POST /api/generators/{name}/run", "description", "Generate a dataset with custom parameters (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/dataset-types", "description", "List registered dataset types (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/schemas", "description", "List dataset payload schemas (protected)")
+	slog.Debug("Endpoint", "route", "GET /api/schemas/{name}?version=N", "description", "Get one payload schema (protected)")
+	slog.Debug("Endpoint", "route", "POST /api/admin/dataset-types", "description", "Register a dataset type (admin)")
+	slog.Debug("Endpoint", "route", "PUT|DELETE /api/admin/dataset-types/{name}", "description", "Update or remove a dataset type (admin)")
+	slog.Debug("Endpoint", "route", "GET|POST /api/admin/users", "description", "List or create users (admin)")
+	slog.Debug("Endpoint", "route", "GET|PATCH|DELETE /api/admin/users/{id}", "description", "Read, update or delete a user (admin)")
+	slog.Debug("Protected GET routes need the viewer role, other methods the editor role")
+
+	if err := http.ListenAndServe(serverAddr, requestIDMiddleware(accessLogMiddleware(metricsMiddleware(routes)))); err != nil {
+		fatal("Server failed to start", err)
 	}
+}
 
-	// Generate real computer science datasets
-	csDatasets, err := generateRealCSData()
-	if err != nil {
-		return fmt.Errorf("failed to generate CS data: %w", err)
+// runCommand runs a one-off subcommand instead of starting the server
+func runCommand(config *Config, name string, args []string) error {
+	if err := connectDatabase(config); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer database.Close()
 
-	// Insert physics datasets
-	for _, dataset := range physicsDatasets {
-		if err := insertDataset(dataset); err != nil {
-			return fmt.Errorf("failed to insert physics dataset: %w", err)
+	switch name {
+	case "migrate":
+		return migrateCommand(args)
+	case "seed":
+		if err := runMigrations(); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
 		}
-	}
-
-	// Insert computer science datasets
-	for _, dataset := range csDatasets {
-		if err := insertDataset(dataset); err != nil {
-			return fmt.Errorf("failed to insert CS dataset: %w", err)
+		return seedCommand(config, args)
+	case "keys":
+		if err := runMigrations(); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
 		}
+		return keysCommand(config, args)
+	default:
+		return fmt.Errorf("unknown command %q (available: migrate, seed, keys)", name)
 	}
-
-	log.Printf("Real-world data generation completed successfully")
-	return nil
 }
 
-// insertDataset inserts a single dataset into the database
-func insertDataset(dataset map[string]interface{}) error {
-	// Convert data to JSON
-	dataJSON, err := json.Marshal(dataset["data"])
-	if err != nil {
-		return fmt.Errorf("failed to marshal data to JSON: %w", err)
-	}
-
-	// Set timestamps
-	now := time.Now()
-	createdAt := now.Add(-time.Duration(len(dataset)) * 24 * time.Hour) // Stagger creation dates
-	updatedAt := now
-
-	// Insert into database
-	query := `
-		INSERT INTO analytics_data (title, description, data_type, data, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
-
-	_, err = database.GetDB().Exec(
-		query,
-		dataset["title"],
-		dataset["description"],
-		dataset["data_type"],
-		dataJSON,
-		createdAt,
-		updatedAt,
-	)
+// connectDatabase opens the global database connection
+func connectDatabase(config *Config) error {
+	dbConfig := &db.Config{
+		DBHost:     config.DBHost,
+		DBPort:     config.DBPort,
+		DBUser:     config.DBUser,
+		DBPassword: config.DBPassword,
+		DBName:     config.DBName,
+	}
 
+	var err error
+	database, err = db.NewDatabase(dbConfig)
 	if err != nil {
-		return fmt.Errorf("failed to insert dataset into database: %w", err)
+		return err
 	}
 
+	slog.Info("Connected to database")
 	return nil
 }
 
+// healthCheckHandler serves GET /health; the access log samples it, see
+// LOG_HEALTH_SAMPLE
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	// Log health check requests for debugging
-	log.Printf("Health check requested from %s", r.RemoteAddr)
-	
-	if r.Method != http.MethodGet {
-		log.Printf("Health check failed: invalid method %s", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Simple health check - don't depend on database
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"healthy"}`))
-	log.Printf("Health check successful")
 }
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fresherpaint/backend/metrics"
+)
+
+// datasetStatsTTL is how long dataset counts and sizes are reused between
+// scrapes, so frequent scrapes do not scan analytics_data each time
+const datasetStatsTTL = 30 * time.Second
+
+// Login results counted by auth_logins_total
+const (
+	loginSuccess = "success"
+	loginFailure = "failure"
+	loginBlocked = "blocked"
+)
+
+// appMetrics holds everything served on GET /metrics
+var appMetrics = newAppMetrics()
+
+type appMetricsSet struct {
+	registry *metrics.Registry
+	// token, if set, must be sent as a bearer token to read the metrics
+	token string
+
+	httpRequests     *metrics.CounterVec
+	httpDuration     *metrics.HistogramVec
+	logins           *metrics.CounterVec
+	tokenValidations *metrics.CounterVec
+
+	datasets datasetStatsCache
+}
+
+func newAppMetrics() *appMetricsSet {
+	registry := metrics.NewRegistry()
+	m := &appMetricsSet{
+		registry: registry,
+		httpRequests: registry.NewCounterVec("http_requests_total",
+			"HTTP requests served, by method, route pattern and status.",
+			"method", "route", "status"),
+		httpDuration: registry.NewHistogramVec("http_request_duration_seconds",
+			"Time to serve HTTP requests, by method, route pattern and status.",
+			metrics.DefaultBuckets, "method", "route", "status"),
+		logins: registry.NewCounterVec("auth_logins_total",
+			"Login attempts by result: success, failure (bad credentials) or blocked (backed off).",
+			"result"),
+		tokenValidations: registry.NewCounterVec("auth_token_validation_failures_total",
+			"Rejected access and refresh tokens, by token kind and reason.",
+			"token", "reason"),
+	}
+
+	poolGauge := func(name, help string, read func(s sql.DBStats) float64) {
+		registry.NewGaugeFunc(name, help, nil, func() []metrics.Sample { return dbPoolSample(read) })
+	}
+	poolCounter := func(name, help string, read func(s sql.DBStats) float64) {
+		registry.NewCounterFunc(name, help, nil, func() []metrics.Sample { return dbPoolSample(read) })
+	}
+	poolGauge("db_max_open_connections", "Maximum number of open database connections (0 is unlimited).",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	poolGauge("db_open_connections", "Open database connections, in use or idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	poolGauge("db_in_use_connections", "Database connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	poolGauge("db_idle_connections", "Idle database connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	poolCounter("db_wait_count_total", "Times a query waited for a free database connection.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	poolCounter("db_wait_duration_seconds_total", "Total time spent waiting for a free database connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	poolCounter("db_max_idle_closed_total", "Connections closed because of the idle connection limit.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	poolCounter("db_max_idle_time_closed_total", "Connections closed because they were idle too long.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	poolCounter("db_max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+
+	registry.NewGaugeFunc("datasets", "Stored datasets by data_type.", []string{"data_type"},
+		func() []metrics.Sample {
+			return m.datasets.samples(func(s datasetStats) float64 { return float64(s.count) })
+		})
+	registry.NewGaugeFunc("datasets_size_bytes", "Stored size of dataset payloads by data_type.", []string{"data_type"},
+		func() []metrics.Sample {
+			return m.datasets.samples(func(s datasetStats) float64 { return float64(s.bytes) })
+		})
+
+	return m
+}
+
+// InitializeMetrics applies the metrics settings
+func InitializeMetrics(config *Config) error {
+	appMetrics.token = config.MetricsToken
+	return nil
+}
+
+// metricsHandler serves GET /metrics in the Prometheus text format. When
+// METRICS_TOKEN is set, scrapers must send it as a bearer token.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if appMetrics.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(appMetrics.token)) != 1 {
+			writeError(w, apiError(CodeUnauthorized, "Metrics token required"))
+			return
+		}
+	}
+	appMetrics.registry.Handler().ServeHTTP(w, r)
+}
+
+// metricsMiddleware counts and times every request by the route pattern
+// that served it, not the raw path, so IDs in URLs do not create new series.
+// It must wrap the router directly: the router records the pattern on the
+// request it is given.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		// Method patterns look like "GET /path"; the method has its own label
+		route := r.Pattern
+		if _, path, found := strings.Cut(route, " "); found {
+			route = path
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(rec.status)
+		appMetrics.httpRequests.Inc(r.Method, route, status)
+		appMetrics.httpDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	})
+}
+
+// countLogin records the result of a login attempt
+func countLogin(result string) {
+	appMetrics.logins.Inc(result)
+}
+
+// countTokenFailure records a rejected token; kind is access or refresh
+func countTokenFailure(kind, reason string) {
+	appMetrics.tokenValidations.Inc(kind, reason)
+}
+
+// dbPoolSample reads one value from the pool stats, or nothing before the
+// database is connected
+func dbPoolSample(read func(s sql.DBStats) float64) []metrics.Sample {
+	if database == nil {
+		return nil
+	}
+	return []metrics.Sample{{Value: read(database.GetDB().Stats())}}
+}
+
+// datasetStats is the number and stored size of datasets of one type
+type datasetStats struct {
+	dataType string
+	count    int64
+	bytes    int64
+}
+
+// datasetStatsCache queries dataset counts and sizes at most once per
+// datasetStatsTTL
+type datasetStatsCache struct {
+	mu      sync.Mutex
+	fetched time.Time
+	stats   []datasetStats
+}
+
+func (c *datasetStatsCache) samples(value func(s datasetStats) float64) []metrics.Sample {
+	var samples []metrics.Sample
+	for _, s := range c.get() {
+		samples = append(samples, metrics.Sample{LabelValues: []string{s.dataType}, Value: value(s)})
+	}
+	return samples
+}
+
+// get returns the cached stats, refreshing them when they are stale. If the
+// query fails the previous stats are kept, so a scrape never fails on it.
+func (c *datasetStatsCache) get() []datasetStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if database == nil || time.Since(c.fetched) < datasetStatsTTL {
+		return c.stats
+	}
+	stats, err := queryDatasetStats()
+	if err != nil {
+		slog.Warn("Failed to collect dataset metrics", "error", err)
+		return c.stats
+	}
+	c.stats, c.fetched = stats, time.Now()
+	return c.stats
+}
+
+// queryDatasetStats counts datasets per registered type, including types
+// with none, and sums the stored size of their data payloads
+func queryDatasetStats() ([]datasetStats, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT t.name, COUNT(d.id), COALESCE(SUM(pg_column_size(d.data)), 0)
+		FROM dataset_types t
+		LEFT JOIN analytics_data d ON d.data_type = t.name
+		GROUP BY t.name
+		ORDER BY t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []datasetStats
+	for rows.Next() {
+		var s datasetStats
+		if err := rows.Scan(&s.dataType, &s.count, &s.bytes); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
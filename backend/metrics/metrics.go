@@ -0,0 +1,276 @@
+// Package metrics is a small Prometheus client: counters, histograms and
+// values read at scrape time, served in the Prometheus text exposition
+// format. Labelled series are created on first use.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds suited to HTTP
+// request latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry holds the metrics exposed by one endpoint, in registration order
+type Registry struct {
+	mu       sync.Mutex
+	families []collector
+	names    map[string]bool
+}
+
+// collector writes one metric family
+type collector interface {
+	write(w io.Writer)
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.families = append(r.families, c)
+}
+
+// Write writes every metric in the text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	families := append([]collector(nil), r.families...)
+	r.mu.Unlock()
+
+	for _, family := range families {
+		family.write(w)
+	}
+}
+
+// Handler serves the registry to Prometheus
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// desc is the name, help text and label names of a family
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind)
+}
+
+// writeSample writes one line; extra is an additional label such as le
+func (d *desc) writeSample(w io.Writer, suffix string, values []string, extra string, value float64) {
+	var labels []string
+	for i, name := range d.labels {
+		labels = append(labels, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extra != "" {
+		labels = append(labels, extra)
+	}
+
+	line := d.name + suffix
+	if len(labels) > 0 {
+		line += "{" + strings.Join(labels, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s %s\n", line, formatFloat(value))
+}
+
+// key joins label values into a map key
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec is a counter with labels
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec registers a counter
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, kind: "counter", labels: labels}, series: map[string]*counterSeries{}}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series
+func (c *CounterVec) Add(v float64, values ...string) {
+	key := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.writeHeader(w)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		c.writeSample(w, "", s.values, "", s.value)
+	}
+}
+
+// HistogramVec is a histogram with labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: sorted,
+		series:  map[string]*histogramSeries{},
+	}
+	r.register(name, h)
+	return h
+}
+
+// Observe records v in the series with the given label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	bucket := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if bucket < len(h.buckets) {
+		s.counts[bucket]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			h.writeSample(w, "_bucket", s.values, `le="`+formatFloat(bound)+`"`, float64(cumulative))
+		}
+		h.writeSample(w, "_bucket", s.values, `le="+Inf"`, float64(s.count))
+		h.writeSample(w, "_sum", s.values, "", s.sum)
+		h.writeSample(w, "_count", s.values, "", float64(s.count))
+	}
+}
+
+// Sample is one labelled value returned by a Func
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// Func is a gauge or counter whose samples are read at scrape time
+type Func struct {
+	desc
+	read func() []Sample
+}
+
+// NewGaugeFunc registers a gauge read at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, read func() []Sample) {
+	r.register(name, &Func{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, read: read})
+}
+
+// NewCounterFunc registers a counter read at scrape time, for totals kept
+// elsewhere such as sql.DBStats
+func (r *Registry) NewCounterFunc(name, help string, labels []string, read func() []Sample) {
+	r.register(name, &Func{desc: desc{name: name, help: help, kind: "counter", labels: labels}, read: read})
+}
+
+func (f *Func) write(w io.Writer) {
+	f.writeHeader(w)
+	for _, s := range f.read() {
+		f.key(s.LabelValues)
+		f.writeSample(w, "", s.LabelValues, "", s.Value)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
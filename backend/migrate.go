@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"fresherpaint/backend/db"
+)
+
+// migrationsDir holds the numbered SQL migration files
+const migrationsDir = "migrations"
+
+// runMigrations applies every pending migration, refusing to continue if an
+// already applied migration file has changed
+func runMigrations() error {
+	slog.Info("Running database migrations")
+
+	applied, err := db.NewMigrator(database, migrationsDir).Up()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range applied {
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	slog.Info("Database migrations completed", "applied", len(applied))
+	return nil
+}
+
+// migrateCommand implements `main migrate up|down|status`
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [-steps N] | status")
+	}
+
+	migrator := db.NewMigrator(database, migrationsDir)
+
+	switch args[0] {
+	case "up":
+		return runMigrations()
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		reverted, err := migrator.Down(*steps)
+		for _, migration := range reverted {
+			slog.Info("Reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.Applied {
+				state = "applied"
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			if status.Drifted {
+				state = "checksum mismatch"
+			}
+			if status.Missing {
+				state = "file missing"
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down or status)", args[0])
+	}
+}
@@ -0,0 +1,2 @@
+-- Revert the initial schema
+DROP TABLE IF EXISTS analytics_data;
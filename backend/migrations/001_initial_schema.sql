@@ -14,6 +14,3 @@ CREATE TABLE IF NOT EXISTS analytics_data (
 CREATE INDEX IF NOT EXISTS idx_analytics_data_type ON analytics_data(data_type);
 CREATE INDEX IF NOT EXISTS idx_analytics_created_at ON analytics_data(created_at);
 CREATE INDEX IF NOT EXISTS idx_analytics_data_gin ON analytics_data USING GIN (data);
-
--- Sample data is now handled by the cleanupAndRegenerateData() function in main.go
--- This ensures fresh data on each server restart without duplicates
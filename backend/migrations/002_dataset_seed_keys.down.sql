@@ -0,0 +1,3 @@
+-- Remove seed key tracking
+DROP INDEX IF EXISTS idx_analytics_seed_key;
+ALTER TABLE analytics_data DROP COLUMN IF EXISTS seed_key;
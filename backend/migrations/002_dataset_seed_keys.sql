@@ -0,0 +1,4 @@
+-- Track built-in datasets by a stable seed key so seeding only inserts
+-- the ones that are missing (see seed.go)
+ALTER TABLE analytics_data ADD COLUMN IF NOT EXISTS seed_key VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_seed_key ON analytics_data(seed_key);
@@ -0,0 +1,6 @@
+-- Restore the hard-coded data_type CHECK; fails if other types are in use
+ALTER TABLE analytics_data DROP CONSTRAINT IF EXISTS analytics_data_data_type_fkey;
+ALTER TABLE analytics_data
+    ADD CONSTRAINT analytics_data_data_type_check
+    CHECK (data_type IN ('physics', 'computer_science'));
+DROP TABLE IF EXISTS dataset_types;
@@ -0,0 +1,18 @@
+-- Registry of dataset types, replacing the hard-coded CHECK on analytics_data.data_type
+CREATE TABLE IF NOT EXISTS dataset_types (
+    name VARCHAR(50) PRIMARY KEY,
+    display_name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    data_schema JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO dataset_types (name, display_name, description) VALUES
+    ('physics', 'Physics', 'Experimental physics data and quantum measurements'),
+    ('computer_science', 'Computer Science', 'Algorithm benchmarks, model training and network performance')
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE analytics_data DROP CONSTRAINT IF EXISTS analytics_data_data_type_check;
+ALTER TABLE analytics_data
+    ADD CONSTRAINT analytics_data_data_type_fkey
+    FOREIGN KEY (data_type) REFERENCES dataset_types(name) ON UPDATE CASCADE;
@@ -0,0 +1,4 @@
+-- Remove the dataset list pagination indexes
+DROP INDEX IF EXISTS idx_analytics_title_id;
+DROP INDEX IF EXISTS idx_analytics_updated_at_id;
+DROP INDEX IF EXISTS idx_analytics_created_at_id;
@@ -0,0 +1,5 @@
+-- Composite indexes for keyset pagination of the dataset list: each sort
+-- key paired with id, matching the ORDER BY in queryAnalyticsData
+CREATE INDEX IF NOT EXISTS idx_analytics_created_at_id ON analytics_data(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_analytics_updated_at_id ON analytics_data((COALESCE(updated_at, created_at)), id);
+CREATE INDEX IF NOT EXISTS idx_analytics_title_id ON analytics_data(title, id);
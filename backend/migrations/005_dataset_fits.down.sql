@@ -0,0 +1,2 @@
+-- Remove stored fit results
+DROP TABLE IF EXISTS dataset_fits;
@@ -0,0 +1,12 @@
+-- Signal-plus-background fit results, kept with the dataset they were
+-- computed from and removed with it
+CREATE TABLE IF NOT EXISTS dataset_fits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset_id UUID NOT NULL REFERENCES analytics_data(id) ON DELETE CASCADE,
+    path VARCHAR(255) NOT NULL,
+    result JSONB NOT NULL,
+    dataset_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_fits_dataset ON dataset_fits(dataset_id, created_at);
@@ -0,0 +1,2 @@
+-- Remove user accounts
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,3 @@
+-- Remove refresh tokens and the access token revocation list
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
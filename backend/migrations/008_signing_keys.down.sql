@@ -0,0 +1,2 @@
+-- Remove persisted signing keys; tokens they signed stop verifying
+DROP TABLE IF EXISTS signing_keys;
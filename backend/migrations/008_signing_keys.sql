@@ -0,0 +1,10 @@
+-- Keys that sign access tokens. The newest key without retired_at signs;
+-- retired keys still verify tokens until expires_at.
+CREATE TABLE IF NOT EXISTS signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL CHECK (algorithm IN ('HS256', 'RS256', 'EdDSA')),
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,2 @@
+-- Remove the per-user access token revocations
+DROP TABLE IF EXISTS revoked_users;
@@ -0,0 +1,11 @@
+-- Users whose access tokens issued up to revoked_at no longer work, because
+-- their role changed or they were deleted. There is no foreign key so a
+-- deleted user's row stays; rows are removed once every token issued
+-- before revoked_at has expired anyway.
+CREATE TABLE IF NOT EXISTS revoked_users (
+    user_id UUID PRIMARY KEY,
+    revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_users_revoked_at ON revoked_users(revoked_at);
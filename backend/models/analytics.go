@@ -1,17 +1,26 @@
 package models
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
+// AnalyticsType is the name of a registered dataset type (see DatasetType)
 type AnalyticsType string
 
+// Built-in dataset types, registered by the dataset_types migration
 const (
 	AnalyticsTypePhysics   AnalyticsType = "physics"
 	AnalyticsTypeCS        AnalyticsType = "computer_science"
 )
 
+// TypeChecker reports whether a dataset type is registered
+type TypeChecker func(AnalyticsType) bool
+
 type AnalyticsData struct {
 	ID          string        `json:"id"`
 	Title       string        `json:"title"`
@@ -31,3 +40,105 @@ type AnalyticsDataDB struct {
 	CreatedAt   time.Time    `db:"created_at"`
 	UpdatedAt   sql.NullTime `db:"updated_at"`
 }
+
+// AnalyticsDataInput is the request body for creating or replacing a dataset
+type AnalyticsDataInput struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	DataType    AnalyticsType   `json:"data_type"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Validate checks that every required field is present and well formed
+func (in *AnalyticsDataInput) Validate(known TypeChecker) error {
+	var problems ValidationErrors
+	problems = append(problems, validateTitle(&in.Title)...)
+	problems = append(problems, validateDataType(in.DataType, known)...)
+	problems = append(problems, validateData(in.Data)...)
+	return validationError(problems)
+}
+
+// AnalyticsDataPatch is the request body for partially updating a dataset.
+// Nil fields are left unchanged; data is replaced as a whole when present.
+type AnalyticsDataPatch struct {
+	Title       *string         `json:"title"`
+	Description *string         `json:"description"`
+	DataType    *AnalyticsType  `json:"data_type"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Validate checks the fields that are present in the patch
+func (p *AnalyticsDataPatch) Validate(known TypeChecker) error {
+	var problems ValidationErrors
+	if p.Title != nil {
+		problems = append(problems, validateTitle(p.Title)...)
+	}
+	if p.DataType != nil {
+		problems = append(problems, validateDataType(*p.DataType, known)...)
+	}
+	if p.Data != nil {
+		problems = append(problems, validateData(p.Data)...)
+	}
+	if p.Title == nil && p.Description == nil && p.DataType == nil && p.Data == nil {
+		problems = append(problems, FieldError{"", "at least one field must be provided"})
+	}
+	return validationError(problems)
+}
+
+func validateTitle(title *string) ValidationErrors {
+	*title = strings.TrimSpace(*title)
+	if *title == "" {
+		return ValidationErrors{{"title", "title is required"}}
+	}
+	if len(*title) > 255 {
+		return ValidationErrors{{"title", "title must be at most 255 characters"}}
+	}
+	return nil
+}
+
+func validateDataType(dataType AnalyticsType, known TypeChecker) ValidationErrors {
+	if dataType == "" {
+		return ValidationErrors{{"data_type", "data_type is required"}}
+	}
+	if !known(dataType) {
+		return ValidationErrors{{"data_type", fmt.Sprintf("data_type %q is not a registered dataset type", dataType)}}
+	}
+	return nil
+}
+
+func validateData(data json.RawMessage) ValidationErrors {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return ValidationErrors{{"data", "data is required"}}
+	}
+	if trimmed[0] != '{' {
+		return ValidationErrors{{"data", "data must be a JSON object"}}
+	}
+	return nil
+}
+
+// FieldError is a problem with one field of a request body. Field is empty
+// when the problem concerns the body as a whole.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every problem found in a request body
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validationError returns problems as an error, or nil if there are none
+func validationError(problems ValidationErrors) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
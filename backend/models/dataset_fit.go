@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DatasetFit is a stored fit of a numeric series of a dataset.
+// DatasetUpdatedAt records the dataset version the fit was computed from.
+type DatasetFit struct {
+	ID               string          `json:"id"`
+	DatasetID        string          `json:"dataset_id"`
+	Path             string          `json:"path"`
+	Result           json.RawMessage `json:"result"`
+	DatasetUpdatedAt time.Time       `json:"dataset_updated_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var datasetTypeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// DatasetType is a registered kind of dataset, such as physics or finance
+type DatasetType struct {
+	Name        string          `json:"name"`
+	DisplayName string          `json:"display_name"`
+	Description string          `json:"description"`
+	DataSchema  json.RawMessage `json:"data_schema,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// DatasetTypeInput is the request body for registering or updating a dataset type.
+// Name is taken from the URL when updating.
+type DatasetTypeInput struct {
+	Name        string          `json:"name"`
+	DisplayName string          `json:"display_name"`
+	Description string          `json:"description"`
+	DataSchema  json.RawMessage `json:"data_schema"`
+}
+
+// Validate checks the dataset type fields
+func (in *DatasetTypeInput) Validate() error {
+	var problems ValidationErrors
+
+	if !datasetTypeNamePattern.MatchString(in.Name) {
+		problems = append(problems, FieldError{"name", "name must be 1-50 lowercase letters, digits or underscores, starting with a letter"})
+	}
+
+	in.DisplayName = strings.TrimSpace(in.DisplayName)
+	if in.DisplayName == "" {
+		problems = append(problems, FieldError{"display_name", "display_name is required"})
+	} else if len(in.DisplayName) > 255 {
+		problems = append(problems, FieldError{"display_name", "display_name must be at most 255 characters"})
+	}
+
+	if in.DataSchema != nil {
+		schema := strings.TrimSpace(string(in.DataSchema))
+		if schema == "null" {
+			in.DataSchema = nil
+		} else if !strings.HasPrefix(schema, "{") {
+			problems = append(problems, FieldError{"data_schema", "data_schema must be a JSON object"})
+		}
+	}
+
+	return validationError(problems)
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Role grants a user access to a set of routes. Roles are ordered: each one
+// includes the access of the roles below it.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // read datasets
+	RoleEditor Role = "editor" // create, change and delete datasets
+	RoleAdmin  Role = "admin"  // manage users and dataset types
+)
+
+// Roles lists every role from least to most privileged
+var Roles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+func (r Role) rank() int {
+	for i, role := range Roles {
+		if role == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	return r.rank() >= 0
+}
+
+// Allows reports whether a user with role r may use a route requiring role
+// required
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && r.rank() >= required.rank()
+}
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,49}$`)
+
+// Password length limits; bcrypt ignores everything after 72 bytes
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// User is an account that can log in. The password hash is never serialised.
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Role         Role      `json:"role"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UserInput is the request body for creating a user
+type UserInput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     Role   `json:"role"`
+}
+
+// Validate checks the user fields; usernames are normalised to lower case
+func (in *UserInput) Validate() error {
+	var problems ValidationErrors
+
+	in.Username = strings.ToLower(strings.TrimSpace(in.Username))
+	if !usernamePattern.MatchString(in.Username) {
+		problems = append(problems, FieldError{"username", "username must be 3-50 lowercase letters, digits, dots, dashes or underscores, starting with a letter or digit"})
+	}
+	problems = append(problems, validatePassword(in.Password)...)
+	problems = append(problems, validateRole(in.Role)...)
+
+	return validationError(problems)
+}
+
+// UserPatch is the request body for updating a user. Nil fields are left
+// unchanged.
+type UserPatch struct {
+	Password *string `json:"password"`
+	Role     *Role   `json:"role"`
+}
+
+// Validate checks the fields that are present in the patch
+func (p *UserPatch) Validate() error {
+	var problems ValidationErrors
+
+	if p.Password == nil && p.Role == nil {
+		problems = append(problems, FieldError{"", "at least one of password or role is required"})
+	}
+	if p.Password != nil {
+		problems = append(problems, validatePassword(*p.Password)...)
+	}
+	if p.Role != nil {
+		problems = append(problems, validateRole(*p.Role)...)
+	}
+
+	return validationError(problems)
+}
+
+func validatePassword(password string) ValidationErrors {
+	if len(password) < MinPasswordLength {
+		return ValidationErrors{{"password", fmt.Sprintf("password must be at least %d characters", MinPasswordLength)}}
+	}
+	if len(password) > MaxPasswordLength {
+		return ValidationErrors{{"password", fmt.Sprintf("password must be at most %d bytes", MaxPasswordLength)}}
+	}
+	return nil
+}
+
+func validateRole(role Role) ValidationErrors {
+	if !role.Valid() {
+		return ValidationErrors{{"role", fmt.Sprintf("role must be one of %s, %s or %s", RoleViewer, RoleEditor, RoleAdmin)}}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package montecarlo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Event processes
+const (
+	Signal     = "signal"
+	Background = "background"
+)
+
+// Photon acceptance, modelled on the ATLAS H→γγ selection: both photons in
+// the precision region of the calorimeter outside the barrel/endcap crack,
+// with transverse momenta above fixed fractions of the diphoton mass
+const (
+	maxEta         = 2.37
+	crackEtaLow    = 1.37
+	crackEtaHigh   = 1.52
+	leadingPtFrac  = 0.35
+	trailingPtFrac = 0.25
+)
+
+// Production model of the diphoton system: pT follows a Gamma(2, scale)
+// distribution and rapidity a Gaussian, with the continuum slightly softer
+// than gluon-fusion Higgs production
+const (
+	signalPtScale     = 15.0 // GeV
+	backgroundPtScale = 10.0 // GeV
+	rapiditySpread    = 1.5
+)
+
+// DiphotonConfig configures a diphoton sample
+type DiphotonConfig struct {
+	SignalEvents     int     // H→γγ decays generated
+	BackgroundEvents int     // continuum events generated
+	Mass             float64 // Higgs mass in GeV
+	Width            float64 // natural width of the Breit-Wigner line shape in GeV
+	// The calorimeter energy resolution is σ_E/E = SamplingTerm/√E ⊕ ConstantTerm
+	SamplingTerm float64
+	ConstantTerm float64
+	// MassMin and MassMax bound the reconstructed diphoton mass in GeV
+	MassMin float64
+	MassMax float64
+	// BackgroundSlope is the decay constant of the exponentially falling
+	// continuum in GeV⁻¹
+	BackgroundSlope float64
+}
+
+// Validate checks that the configuration describes a usable sample
+func (c *DiphotonConfig) Validate() error {
+	if c.SignalEvents < 0 || c.BackgroundEvents < 0 || c.SignalEvents+c.BackgroundEvents == 0 {
+		return fmt.Errorf("at least one signal or background event must be generated")
+	}
+	if !(c.MassMin > 0 && c.MassMin < c.MassMax) {
+		return fmt.Errorf("the mass window must satisfy 0 < mass_min < mass_max")
+	}
+	if c.SignalEvents > 0 && (c.Mass <= c.MassMin || c.Mass >= c.MassMax) {
+		return fmt.Errorf("the Higgs mass must lie inside the mass window")
+	}
+	if c.Width < 0 || c.SamplingTerm < 0 || c.ConstantTerm < 0 {
+		return fmt.Errorf("width and resolution terms must not be negative")
+	}
+	return nil
+}
+
+// DiphotonEvent is one accepted event
+type DiphotonEvent struct {
+	Process  string
+	Photons  [2]FourVector // reconstructed photons, leading first
+	Mass     float64       // reconstructed diphoton mass
+	TrueMass float64       // diphoton mass before detector smearing
+}
+
+// DiphotonSample is the accepted events in random order, with the
+// generated and accepted counts per process
+type DiphotonSample struct {
+	Events    []DiphotonEvent
+	Generated map[string]int
+	Accepted  map[string]int
+}
+
+// Acceptance is the accepted fraction of the generated events of a process
+func (s *DiphotonSample) Acceptance(process string) float64 {
+	if s.Generated[process] == 0 {
+		return 0
+	}
+	return float64(s.Accepted[process]) / float64(s.Generated[process])
+}
+
+// GenerateDiphotons simulates pp → H → γγ and the diphoton continuum.
+//
+// Each event produces a diphoton system with a mass drawn from a
+// Breit-Wigner (signal) or a falling exponential (background), boosts it
+// with the production pT and rapidity, and decays it isotropically into two
+// photons. Photon energies are smeared with the calorimeter resolution and
+// the event is kept when both photons pass the acceptance and the
+// reconstructed mass lies in the window. The continuum is generated 10 GeV
+// beyond the window on each side so resolution effects at the edges are
+// modelled.
+func GenerateDiphotons(config DiphotonConfig, rng *rand.Rand) (*DiphotonSample, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	sample := &DiphotonSample{
+		Generated: map[string]int{Signal: config.SignalEvents, Background: config.BackgroundEvents},
+		Accepted:  map[string]int{Signal: 0, Background: 0},
+	}
+
+	for i := 0; i < config.SignalEvents; i++ {
+		mass := breitWigner(config.Mass, config.Width, rng)
+		if event, ok := diphotonEvent(Signal, mass, signalPtScale, config, rng); ok {
+			sample.Events = append(sample.Events, event)
+		}
+	}
+	lo := math.Max(config.MassMin-10, 1)
+	hi := config.MassMax + 10
+	for i := 0; i < config.BackgroundEvents; i++ {
+		mass := fallingExponential(lo, hi, config.BackgroundSlope, rng)
+		if event, ok := diphotonEvent(Background, mass, backgroundPtScale, config, rng); ok {
+			sample.Events = append(sample.Events, event)
+		}
+	}
+
+	rng.Shuffle(len(sample.Events), func(i, j int) {
+		sample.Events[i], sample.Events[j] = sample.Events[j], sample.Events[i]
+	})
+	for _, event := range sample.Events {
+		sample.Accepted[event.Process]++
+	}
+	return sample, nil
+}
+
+// diphotonEvent produces, decays, smears and selects one diphoton system
+func diphotonEvent(process string, mass, ptScale float64, config DiphotonConfig, rng *rand.Rand) (DiphotonEvent, bool) {
+	pt := -ptScale * math.Log(rng.Float64()*rng.Float64()+math.SmallestNonzeroFloat64)
+	parent := FromPtYPhiM(pt, rng.NormFloat64()*rapiditySpread, 2*math.Pi*rng.Float64(), mass)
+
+	// Isotropic two-body decay in the rest frame
+	cosTheta := 2*rng.Float64() - 1
+	sinTheta := math.Sqrt(1 - cosTheta*cosTheta)
+	sinPhi, cosPhi := math.Sincos(2 * math.Pi * rng.Float64())
+	half := mass / 2
+	rest := FourVector{E: half, Px: half * sinTheta * cosPhi, Py: half * sinTheta * sinPhi, Pz: half * cosTheta}
+	mirror := FourVector{E: half, Px: -rest.Px, Py: -rest.Py, Pz: -rest.Pz}
+
+	bx, by, bz := parent.Px/parent.E, parent.Py/parent.E, parent.Pz/parent.E
+	photons := [2]FourVector{
+		smear(rest.Boost(bx, by, bz), config, rng),
+		smear(mirror.Boost(bx, by, bz), config, rng),
+	}
+	if photons[1].Pt() > photons[0].Pt() {
+		photons[0], photons[1] = photons[1], photons[0]
+	}
+
+	reconstructed := photons[0].Add(photons[1]).Mass()
+	if reconstructed < config.MassMin || reconstructed > config.MassMax {
+		return DiphotonEvent{}, false
+	}
+	if !inAcceptance(photons[0]) || !inAcceptance(photons[1]) {
+		return DiphotonEvent{}, false
+	}
+	if photons[0].Pt() < leadingPtFrac*reconstructed || photons[1].Pt() < trailingPtFrac*reconstructed {
+		return DiphotonEvent{}, false
+	}
+
+	return DiphotonEvent{Process: process, Photons: photons, Mass: reconstructed, TrueMass: mass}, true
+}
+
+// smear scales a photon's energy and momentum by the calorimeter response
+func smear(photon FourVector, config DiphotonConfig, rng *rand.Rand) FourVector {
+	resolution := math.Hypot(config.SamplingTerm/math.Sqrt(photon.E), config.ConstantTerm)
+	factor := 1 + resolution*rng.NormFloat64()
+	if factor <= 0 {
+		factor = math.SmallestNonzeroFloat64
+	}
+	return photon.Scale(factor)
+}
+
+func inAcceptance(photon FourVector) bool {
+	eta := math.Abs(photon.Eta())
+	return eta < maxEta && (eta < crackEtaLow || eta >= crackEtaHigh)
+}
+
+// breitWigner samples a non-relativistic Breit-Wigner of the given width,
+// truncated at 50 widths (or 1 GeV for very narrow states) from the peak so
+// the Cauchy tails cannot produce unphysical masses
+func breitWigner(mass, width float64, rng *rand.Rand) float64 {
+	if width == 0 {
+		return mass
+	}
+	limit := math.Max(50*width, 1)
+	for {
+		m := mass + width/2*math.Tan(math.Pi*(rng.Float64()-0.5))
+		if math.Abs(m-mass) <= limit && m > 0 {
+			return m
+		}
+	}
+}
+
+// fallingExponential samples exp(-slope·m) on [lo, hi] by inverting its CDF
+func fallingExponential(lo, hi, slope float64, rng *rand.Rand) float64 {
+	u := rng.Float64()
+	if math.Abs(slope) < 1e-12 {
+		return lo + u*(hi-lo)
+	}
+	return lo - math.Log1p(-u*(-math.Expm1(-slope*(hi-lo))))/slope
+}
@@ -0,0 +1,94 @@
+// Package montecarlo generates simulated particle physics events: Higgs
+// boson decays to two photons over a falling diphoton continuum, seen
+// through a calorimeter with finite resolution and acceptance, and cosmic
+// ray muons at sea level. Every generator draws from a caller-supplied
+// random source, so a seed reproduces its events.
+package montecarlo
+
+import "math"
+
+// FourVector is an energy-momentum four-vector in GeV, with z along the
+// beam (collider events) or pointing up (cosmic muons)
+type FourVector struct {
+	E  float64 `json:"e"`
+	Px float64 `json:"px"`
+	Py float64 `json:"py"`
+	Pz float64 `json:"pz"`
+}
+
+// FromPtYPhiM builds a four-vector from transverse momentum, rapidity,
+// azimuth and mass
+func FromPtYPhiM(pt, y, phi, m float64) FourVector {
+	mt := math.Sqrt(m*m + pt*pt)
+	sin, cos := math.Sincos(phi)
+	return FourVector{
+		E:  mt * math.Cosh(y),
+		Px: pt * cos,
+		Py: pt * sin,
+		Pz: mt * math.Sinh(y),
+	}
+}
+
+// Add returns the sum of two four-vectors
+func (v FourVector) Add(w FourVector) FourVector {
+	return FourVector{E: v.E + w.E, Px: v.Px + w.Px, Py: v.Py + w.Py, Pz: v.Pz + w.Pz}
+}
+
+// Scale multiplies every component by f
+func (v FourVector) Scale(f float64) FourVector {
+	return FourVector{E: v.E * f, Px: v.Px * f, Py: v.Py * f, Pz: v.Pz * f}
+}
+
+// P is the magnitude of the three-momentum
+func (v FourVector) P() float64 {
+	return math.Sqrt(v.Px*v.Px + v.Py*v.Py + v.Pz*v.Pz)
+}
+
+// Pt is the transverse momentum
+func (v FourVector) Pt() float64 {
+	return math.Hypot(v.Px, v.Py)
+}
+
+// Mass is the invariant mass; a slightly spacelike vector from rounding
+// gives 0
+func (v FourVector) Mass() float64 {
+	m2 := v.E*v.E - v.Px*v.Px - v.Py*v.Py - v.Pz*v.Pz
+	if m2 <= 0 {
+		return 0
+	}
+	return math.Sqrt(m2)
+}
+
+// Eta is the pseudorapidity; it is ±Inf along the z axis
+func (v FourVector) Eta() float64 {
+	p := v.P()
+	if p == math.Abs(v.Pz) {
+		return math.Copysign(math.Inf(1), v.Pz)
+	}
+	return 0.5 * math.Log((p+v.Pz)/(p-v.Pz))
+}
+
+// Boost returns v seen from a frame moving with velocity -β, i.e. boosts v
+// by β = (bx, by, bz)
+func (v FourVector) Boost(bx, by, bz float64) FourVector {
+	b2 := bx*bx + by*by + bz*bz
+	if b2 == 0 {
+		return v
+	}
+	gamma := 1 / math.Sqrt(1-b2)
+	bp := bx*v.Px + by*v.Py + bz*v.Pz
+	g2 := (gamma - 1) / b2
+	return FourVector{
+		E:  gamma * (v.E + bp),
+		Px: v.Px + g2*bp*bx + gamma*bx*v.E,
+		Py: v.Py + g2*bp*by + gamma*by*v.E,
+		Pz: v.Pz + g2*bp*bz + gamma*bz*v.E,
+	}
+}
+
+// Rounded returns v with each component rounded to 0.1 MeV, the precision
+// stored in datasets
+func (v FourVector) Rounded() FourVector {
+	r := func(x float64) float64 { return math.Round(x*1e4) / 1e4 }
+	return FourVector{E: r(v.E), Px: r(v.Px), Py: r(v.Py), Pz: r(v.Pz)}
+}
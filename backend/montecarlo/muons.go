@@ -0,0 +1,155 @@
+package montecarlo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MuonMass is the muon rest mass in GeV
+const MuonMass = 0.1056583755
+
+// Parameters of the zenith angle correction of Guan et al. (2015),
+// arXiv:1509.06176, which accounts for the curvature of the Earth
+const (
+	guanP1 = 0.102573
+	guanP2 = -0.068287
+	guanP3 = 0.958633
+	guanP4 = 0.0407253
+	guanP5 = 0.817285
+)
+
+// Energy loss of a minimum ionising muon in plastic scintillator: most
+// probable value and Landau width in MeV/cm
+const (
+	scintillatorMPV   = 1.75
+	scintillatorWidth = 0.12
+)
+
+// detectorWidth bounds the track length of near-horizontal muons, in cm
+const detectorWidth = 100.0
+
+// MuonConfig configures a cosmic muon sample
+type MuonConfig struct {
+	Events int
+	// MinEnergy and MaxEnergy bound the muon energy in GeV
+	MinEnergy float64
+	MaxEnergy float64
+	// SpectralIndex is the power-law index of the spectrum, 2.7 at sea level
+	SpectralIndex float64
+	// DetectorThickness is the vertical thickness of the scintillator in cm
+	DetectorThickness float64
+}
+
+// Validate checks that the configuration describes a usable sample
+func (c *MuonConfig) Validate() error {
+	if c.Events < 1 {
+		return fmt.Errorf("at least one muon must be generated")
+	}
+	if !(c.MinEnergy > MuonMass && c.MinEnergy < c.MaxEnergy) {
+		return fmt.Errorf("the energy range must satisfy %.3f < min_energy < max_energy", MuonMass)
+	}
+	if c.SpectralIndex <= 1 {
+		return fmt.Errorf("the spectral index must be greater than 1")
+	}
+	if c.DetectorThickness <= 0 {
+		return fmt.Errorf("the detector thickness must be positive")
+	}
+	return nil
+}
+
+// Muon is one simulated cosmic muon crossing the detector
+type Muon struct {
+	Momentum    FourVector // z points up, so Pz < 0 for a downward muon
+	Zenith      float64    // degrees from vertical
+	Azimuth     float64    // degrees
+	EnergyLoss  float64    // MeV/cm
+	TrackLength float64    // cm
+}
+
+// GenerateMuons simulates cosmic ray muons at sea level.
+//
+// Zenith angles follow the cos²θ intensity law, so cos θ is drawn with
+// density ∝ cos²θ per unit solid angle. The energy at that angle is drawn
+// from the Gaisser parametrisation with the low-energy and Earth-curvature
+// corrections of Guan et al.:
+//
+//	dN/dE ∝ [E + 3.64/cos^1.29 θ*]^-γ · (1/(1 + 1.1 E cos θ*/115) + 0.054/(1 + 1.1 E cos θ*/850))
+//
+// with γ the spectral index, by rejection sampling from the shifted power
+// law. Energy loss in the scintillator follows a Moyal approximation to the
+// Landau distribution, and the track length is the slant path through the
+// detector.
+func GenerateMuons(config MuonConfig, rng *rand.Rand) ([]Muon, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	muons := make([]Muon, 0, config.Events)
+	for i := 0; i < config.Events; i++ {
+		cosTheta := math.Cbrt(rng.Float64())
+		for cosTheta == 0 {
+			cosTheta = math.Cbrt(rng.Float64())
+		}
+		sinTheta := math.Sqrt(1 - cosTheta*cosTheta)
+		phi := 2 * math.Pi * rng.Float64()
+
+		energy := gaisserEnergy(cosTheta, config, rng)
+		p := math.Sqrt(energy*energy - MuonMass*MuonMass)
+		sinPhi, cosPhi := math.Sincos(phi)
+
+		length := config.DetectorThickness / cosTheta
+		if sinTheta > 0 {
+			length = math.Min(length, detectorWidth/sinTheta)
+		}
+
+		// Moyal variate: −ln Z² for a standard normal Z
+		z := rng.NormFloat64()
+		for z == 0 {
+			z = rng.NormFloat64()
+		}
+		loss := scintillatorMPV + scintillatorWidth*-math.Log(z*z)
+
+		muons = append(muons, Muon{
+			Momentum: FourVector{
+				E:  energy,
+				Px: -p * sinTheta * cosPhi,
+				Py: -p * sinTheta * sinPhi,
+				Pz: -p * cosTheta,
+			},
+			Zenith:      math.Acos(cosTheta) * 180 / math.Pi,
+			Azimuth:     phi * 180 / math.Pi,
+			EnergyLoss:  math.Max(loss, 0),
+			TrackLength: length,
+		})
+	}
+	return muons, nil
+}
+
+// gaisserEnergy samples the muon energy at a given zenith angle
+func gaisserEnergy(cosTheta float64, config MuonConfig, rng *rand.Rand) float64 {
+	c := cosThetaStar(cosTheta)
+	shift := 3.64 / math.Pow(c, 1.29)
+	gamma := config.SpectralIndex
+
+	// Proposal (E + shift)^-γ on [MinEnergy, MaxEnergy] by inverse CDF
+	lo := math.Pow(config.MinEnergy+shift, 1-gamma)
+	hi := math.Pow(config.MaxEnergy+shift, 1-gamma)
+
+	const bound = 1.054 // the bracket below never exceeds 1 + 0.054
+	for {
+		e := math.Pow(lo-rng.Float64()*(lo-hi), 1/(1-gamma)) - shift
+		e = math.Min(math.Max(e, config.MinEnergy), config.MaxEnergy)
+		bracket := 1/(1+1.1*e*c/115) + 0.054/(1+1.1*e*c/850)
+		if rng.Float64()*bound <= bracket {
+			return e
+		}
+	}
+}
+
+// cosThetaStar is the zenith angle at the muon production height, corrected
+// for the curvature of the Earth
+func cosThetaStar(cosTheta float64) float64 {
+	num := cosTheta*cosTheta + guanP1*guanP1 + guanP2*math.Pow(cosTheta, guanP3) + guanP4*math.Pow(cosTheta, guanP5)
+	return math.Sqrt(num / (1 + guanP1*guanP1 + guanP2 + guanP4))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fresherpaint/backend/ratelimit"
+	"fresherpaint/backend/router"
+)
+
+// RateLimits holds the limiters shared by the route middleware and the
+// login handler
+type RateLimits struct {
+	// API limits ordinary protected requests, Heavy the endpoints that run
+	// benchmarks, generators or imports; both are keyed by user
+	API   *ratelimit.Limiter
+	Heavy *ratelimit.Limiter
+	// Auth limits login and refresh requests per client IP
+	Auth *ratelimit.Limiter
+	// Failed logins back off per client IP and per username. Only the IP
+	// is ever locked out: locking a username would let anyone lock its
+	// owner out, so the account only slows guessing down with a short delay.
+	// The IP policy is looser because many users can share an address.
+	LoginIP      *ratelimit.Backoff
+	LoginAccount *ratelimit.Backoff
+	// TrustProxy takes the client IP from X-Forwarded-For
+	TrustProxy bool
+}
+
+var (
+	loginIPPolicy = ratelimit.BackoffPolicy{
+		FreeAttempts:    10,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutAfter:    50,
+		LockoutDuration: 30 * time.Minute,
+		Reset:           time.Hour,
+	}
+	loginAccountPolicy = ratelimit.BackoffPolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		Reset:        time.Hour,
+	}
+)
+
+var rateLimits *RateLimits
+
+// InitializeRateLimits builds the limiters from the configured rates
+func InitializeRateLimits(config *Config) error {
+	api, err := newLimiter("RATE_LIMIT_API", config.RateLimitAPI)
+	if err != nil {
+		return err
+	}
+	heavy, err := newLimiter("RATE_LIMIT_HEAVY", config.RateLimitHeavy)
+	if err != nil {
+		return err
+	}
+	auth, err := newLimiter("RATE_LIMIT_AUTH", config.RateLimitAuth)
+	if err != nil {
+		return err
+	}
+
+	rateLimits = &RateLimits{
+		API:          api,
+		Heavy:        heavy,
+		Auth:         auth,
+		LoginIP:      ratelimit.NewBackoff(loginIPPolicy),
+		LoginAccount: ratelimit.NewBackoff(loginAccountPolicy),
+		TrustProxy:   config.TrustProxy,
+	}
+	return nil
+}
+
+func newLimiter(name, value string) (*ratelimit.Limiter, error) {
+	rate, err := ratelimit.ParseRate(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return ratelimit.NewLimiter(rate), nil
+}
+
+// rateLimitMiddleware applies a token bucket per user, or per client IP on
+// public routes. Place it inside authMiddleware so the user is known.
+func rateLimitMiddleware(limiter *ratelimit.Limiter) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + clientIP(r)
+			if claims := requestClaims(r); claims != nil {
+				key = "user:" + claims.UserID
+			}
+
+			if ok, retryAfter := limiter.Allow(key); !ok {
+				writeTooManyRequests(w, retryAfter, "Rate limit exceeded, retry later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeTooManyRequests writes a 429 with Retry-After in whole seconds
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, apiError(CodeRateLimited, "%s", message))
+}
+
+// clientIP is the address of the client. Behind a reverse proxy
+// (TRUST_PROXY=true) it is the right-most X-Forwarded-For entry, the one
+// added by our proxy, since clients can forge the others.
+func clientIP(r *http.Request) string {
+	if rateLimits != nil && rateLimits.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
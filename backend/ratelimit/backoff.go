@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// BackoffPolicy describes how failures delay further attempts. After
+// FreeAttempts failures each further failure blocks the key for BaseDelay,
+// doubling per failure up to MaxDelay. LockoutAfter failures block it for
+// LockoutDuration. Failures are forgotten after Reset without one.
+type BackoffPolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	Reset           time.Duration
+}
+
+// Backoff tracks failures per key under a policy
+type Backoff struct {
+	policy BackoffPolicy
+
+	mu        sync.Mutex
+	entries   map[string]*failures
+	lastSweep time.Time
+}
+
+type failures struct {
+	count        int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// NewBackoff returns a failure tracker for the policy
+func NewBackoff(policy BackoffPolicy) *Backoff {
+	return &Backoff{policy: policy, entries: map[string]*failures{}, lastSweep: time.Now()}
+}
+
+// Blocked returns how long the key must still wait, or 0 if it may try now
+func (b *Backoff) Blocked(key string) time.Duration {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[key]
+	if !ok || !now.Before(entry.blockedUntil) {
+		return 0
+	}
+	return entry.blockedUntil.Sub(now)
+}
+
+// Failure records a failed attempt and returns how long the key is now
+// blocked for
+func (b *Backoff) Failure(key string) time.Duration {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sweep(now)
+
+	entry, ok := b.entries[key]
+	if !ok || now.Sub(entry.last) > b.policy.Reset {
+		entry = &failures{}
+		b.entries[key] = entry
+	}
+	entry.count++
+	entry.last = now
+
+	var delay time.Duration
+	switch {
+	case b.policy.LockoutAfter > 0 && entry.count >= b.policy.LockoutAfter:
+		delay = b.policy.LockoutDuration
+	case entry.count > b.policy.FreeAttempts:
+		delay = b.policy.BaseDelay
+		for i := b.policy.FreeAttempts + 1; i < entry.count && delay < b.policy.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > b.policy.MaxDelay {
+			delay = b.policy.MaxDelay
+		}
+	}
+
+	entry.blockedUntil = now.Add(delay)
+	return delay
+}
+
+// Success forgets the key's failures
+func (b *Backoff) Success(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.entries, key)
+}
+
+// sweep forgets keys whose failures have expired, at most once per Reset
+func (b *Backoff) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < b.policy.Reset {
+		return
+	}
+	for key, entry := range b.entries {
+		if now.Sub(entry.last) > b.policy.Reset && !now.Before(entry.blockedUntil) {
+			delete(b.entries, key)
+		}
+	}
+	b.lastSweep = now
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelays(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy BackoffPolicy
+		want   []time.Duration // delay after each failure
+	}{
+		{
+			name:   "doubling up to the maximum",
+			policy: BackoffPolicy{FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 5 * time.Second, Reset: time.Hour},
+			want:   []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name: "lockout after the limit",
+			policy: BackoffPolicy{
+				FreeAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Minute,
+				LockoutAfter: 4, LockoutDuration: time.Hour, Reset: time.Hour,
+			},
+			want: []time.Duration{0, time.Second, 2 * time.Second, time.Hour, time.Hour},
+		},
+		{
+			name:   "no free attempts",
+			policy: BackoffPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Reset: time.Hour},
+			want:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(tt.policy)
+			for i, want := range tt.want {
+				if got := b.Failure("key"); got != want {
+					t.Errorf("failure %d: delay %v, want %v", i+1, got, want)
+				}
+				blocked := b.Blocked("key")
+				if blocked > want || (want > 0 && blocked < want-time.Second/10) {
+					t.Errorf("failure %d: blocked for %v, want about %v", i+1, blocked, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffKeysAndReset(t *testing.T) {
+	b := NewBackoff(BackoffPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Reset: time.Hour})
+
+	b.Failure("a")
+	b.Failure("a")
+	if b.Blocked("a") == 0 {
+		t.Fatal("key a is not blocked")
+	}
+	if b.Blocked("b") != 0 {
+		t.Error("an unrelated key is blocked")
+	}
+
+	// Success forgets the failures
+	b.Success("a")
+	if b.Blocked("a") != 0 {
+		t.Error("key a is still blocked after a success")
+	}
+	if got := b.Failure("a"); got != time.Second {
+		t.Errorf("first failure after a success: delay %v, want 1s", got)
+	}
+
+	// So does a quiet period longer than Reset
+	b.Failure("a")
+	entry := b.entries["a"]
+	entry.last = entry.last.Add(-2 * time.Hour)
+	entry.blockedUntil = entry.blockedUntil.Add(-2 * time.Hour)
+	if b.Blocked("a") != 0 {
+		t.Error("key a is still blocked after its delay passed")
+	}
+	if got := b.Failure("a"); got != time.Second {
+		t.Errorf("first failure after the reset period: delay %v, want 1s", got)
+	}
+}
+
+func TestBackoffSweep(t *testing.T) {
+	b := NewBackoff(BackoffPolicy{FreeAttempts: 5, Reset: time.Minute})
+	b.Failure("old")
+	b.entries["old"].last = b.entries["old"].last.Add(-2 * time.Minute)
+	b.lastSweep = b.lastSweep.Add(-2 * time.Minute)
+
+	b.Failure("new")
+	if _, ok := b.entries["old"]; ok {
+		t.Error("expired failures were not swept")
+	}
+	if _, ok := b.entries["new"]; !ok {
+		t.Error("recent failures were swept")
+	}
+}
@@ -0,0 +1,132 @@
+// Package ratelimit provides in-memory request limiting: token buckets keyed
+// by client, and exponential backoff with lockout for repeated failures such
+// as wrong passwords. State is per process, so each replica enforces its own
+// limits.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate allows Limit requests per Per, in bursts of up to Limit. The zero
+// Rate is unlimited.
+type Rate struct {
+	Limit int
+	Per   time.Duration
+}
+
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseRate parses "N/s", "N/m" or "N/h"; "off" and "0" mean unlimited
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Rate{}, nil
+	}
+
+	count, unit, ok := strings.Cut(s, "/")
+	per, known := rateUnits[unit]
+	limit, err := strconv.Atoi(count)
+	if !ok || !known || err != nil || limit < 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q (expected N/s, N/m, N/h or off)", s)
+	}
+	return Rate{Limit: limit, Per: per}, nil
+}
+
+// Unlimited reports whether the rate imposes no limit
+func (r Rate) Unlimited() bool {
+	return r.Limit == 0 || r.Per == 0
+}
+
+func (r Rate) String() string {
+	if r.Unlimited() {
+		return "off"
+	}
+	for unit, per := range rateUnits {
+		if per == r.Per {
+			return fmt.Sprintf("%d/%s", r.Limit, unit)
+		}
+	}
+	return fmt.Sprintf("%d/%s", r.Limit, r.Per)
+}
+
+// Limiter is a set of token buckets, one per key. Each bucket holds up to
+// Limit tokens and refills at Limit per Per; a request takes one token.
+type Limiter struct {
+	rate Rate
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter returns a limiter for the given rate
+func NewLimiter(rate Rate) *Limiter {
+	return &Limiter{rate: rate, buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+// Rate is the limiter's configured rate
+func (l *Limiter) Rate() Rate {
+	return l.rate
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it
+// returns false and the time until a token is available. A nil or
+// unlimited limiter allows everything.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.rate.Unlimited() {
+		return true, 0
+	}
+
+	now := time.Now()
+	perToken := l.rate.Per / time.Duration(l.rate.Limit)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.rate.Limit), updated: now}
+		l.buckets[key] = b
+	}
+
+	refill := float64(now.Sub(b.updated)) / float64(perToken)
+	b.tokens = math.Min(float64(l.rate.Limit), b.tokens+refill)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) * float64(perToken))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep forgets buckets that have refilled completely, at most once per
+// refill period; a full bucket is the same as no bucket
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.rate.Per {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.rate.Per {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{"10/s", Rate{10, time.Second}, false},
+		{"60/m", Rate{60, time.Minute}, false},
+		{" 5/h ", Rate{5, time.Hour}, false},
+		{"off", Rate{}, false},
+		{"0", Rate{}, false},
+		{"10", Rate{}, true},
+		{"10/d", Rate{}, true},
+		{"-1/s", Rate{}, true},
+		{"x/s", Rate{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil && !got.Unlimited() && got.String() != strings.TrimSpace(tt.in) {
+			t.Errorf("Rate(%q).String() = %q", tt.in, got.String())
+		}
+	}
+}
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	l := NewLimiter(Rate{Limit: 4, Per: time.Second})
+
+	// A new key gets a full burst
+	for i := 0; i < 4; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok {
+		t.Fatal("request after the burst was allowed")
+	}
+	if wait <= 0 || wait > 250*time.Millisecond {
+		t.Errorf("wait = %v, want up to one token interval of 250ms", wait)
+	}
+
+	// Other keys have their own bucket
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("another key was refused")
+	}
+
+	// Half a second refills two tokens
+	l.buckets["a"].updated = l.buckets["a"].updated.Add(-500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("refilled token %d was refused", i+1)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("more tokens than refilled were allowed")
+	}
+
+	// Refill stops at the burst size
+	l.buckets["a"].updated = l.buckets["a"].updated.Add(-time.Hour)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("a"); ok {
+			allowed++
+		}
+	}
+	if allowed != 4 {
+		t.Errorf("after a long idle period %d requests were allowed, want 4", allowed)
+	}
+}
+
+func TestLimiterWaitGrowsWithDeficit(t *testing.T) {
+	l := NewLimiter(Rate{Limit: 1, Per: time.Minute})
+	l.Allow("a")
+	l.buckets["a"].updated = l.buckets["a"].updated.Add(-15 * time.Second)
+
+	ok, wait := l.Allow("a")
+	if ok {
+		t.Fatal("request was allowed with a quarter token")
+	}
+	if wait < 44*time.Second || wait > 45*time.Second {
+		t.Errorf("wait = %v, want about 45s", wait)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	for _, l := range []*Limiter{nil, NewLimiter(Rate{})} {
+		for i := 0; i < 100; i++ {
+			if ok, wait := l.Allow("a"); !ok || wait != 0 {
+				t.Fatalf("unlimited limiter refused request %d", i+1)
+			}
+		}
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	l := NewLimiter(Rate{Limit: 1, Per: time.Second})
+	l.Allow("idle")
+	l.buckets["idle"].updated = l.buckets["idle"].updated.Add(-2 * time.Second)
+	l.lastSweep = l.lastSweep.Add(-2 * time.Second)
+
+	l.Allow("active")
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("a refilled bucket was not swept")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("the active bucket was swept")
+	}
+}
@@ -1,350 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"math"
-	"math/rand"
-	"time"
-)
-
-// Real physics datasets based on CERN particle collision data
-func generateRealPhysicsData() ([]map[string]interface{}, error) {
-	datasets := []map[string]interface{}{}
-
-	// Dataset 1: LHC Higgs Boson Detection Data (inspired by ATLAS experiment)
-	higgsData := map[string]interface{}{
-		"title":       "LHC Higgs Boson Decay Analysis",
-		"description": "Real collision data from ATLAS experiment showing Higgs boson decay to two photons",
-		"data_type":   "physics",
-		"data": map[string]interface{}{
-			"experiment": "ATLAS",
-			"collision_energy": "13TeV",
-			"luminosity": "139 fb^-1",
-			"measurements": generateHiggsDecayData(),
-			"units": map[string]string{
-				"energy": "GeV",
-				"time": "ns",
-				"momentum": "GeV/c",
-			},
-		},
-	}
-	datasets = append(datasets, higgsData)
-
-	// Dataset 2: Cosmic Ray Muon Detection (inspired by CMS experiment)
-	muonData := map[string]interface{}{
-		"title":       "Cosmic Ray Muon Detection",
-		"description": "High-energy muon detection data from cosmic ray interactions",
-		"data_type":   "physics",
-		"data": map[string]interface{}{
-			"experiment": "CMS",
-			"detector_type": "Muon Chambers",
-			"collisions": generateCosmicRayData(),
-			"background_rate": 2.3, // Hz/cm²
-		},
-	}
-	datasets = append(datasets, muonData)
-
-	// Dataset 3: Quantum Entanglement Measurements
-	quantumData := map[string]interface{}{
-		"title":       "Bell State Quantum Entanglement",
-		"description": "Quantum entanglement correlation measurements violating Bell inequalities",
-		"data_type":   "physics",
-		"data": map[string]interface{}{
-			"experiment_type": "Bell Test",
-			"quantum_measurements": generateQuantumEntanglementData(),
-			"bell_parameter": 2.82, // S > 2 violates Bell inequality
-			"units": map[string]string{
-				"correlation": "dimensionless",
-				"angle": "degrees",
-			},
-		},
-	}
-	datasets = append(datasets, quantumData)
-
-	return datasets, nil
-}
-
-// Real computer science datasets based on modern benchmarks
-func generateRealCSData() ([]map[string]interface{}, error) {
-	datasets := []map[string]interface{}{}
-
-	// Dataset 1: Modern Sorting Algorithm Benchmarks
-	sortingData := map[string]interface{}{
-		"title":       "Modern Sorting Algorithm Performance",
-		"description": "Comprehensive benchmarks of sorting algorithms on various data distributions",
-		"data_type":   "computer_science",
-		"data": map[string]interface{}{
-			"algorithms": generateSortingBenchmarks(),
-			"test_environment": map[string]string{
-				"cpu": "Intel i9-13900K",
-				"memory": "32GB DDR5-5600",
-				"compiler": "GCC 13.2",
-				"optimization": "-O3",
-			},
-		},
-	}
-	datasets = append(datasets, sortingData)
-
-	// Dataset 2: Machine Learning Training Performance
-	mlData := map[string]interface{}{
-		"title":       "Deep Learning Model Training Metrics",
-		"description": "Training performance data for various neural network architectures",
-		"data_type":   "computer_science",
-		"data": map[string]interface{}{
-			"models": generateMLTrainingData(),
-			"dataset": "ImageNet-1K",
-			"hardware": "NVIDIA RTX 4090",
-		},
-	}
-	datasets = append(datasets, mlData)
-
-	// Dataset 3: Network Performance Analysis
-	networkData := map[string]interface{}{
-		"title":       "5G Network Performance Analysis",
-		"description": "Real-world 5G network performance metrics across different conditions",
-		"data_type":   "computer_science",
-		"data": map[string]interface{}{
-			"metrics": generate5GNetworkData(),
-			"test_locations": []string{"Urban", "Suburban", "Rural"},
-			"units": map[string]string{
-				"bandwidth": "Mbps",
-				"latency": "ms",
-				"packet_loss": "%",
-			},
-		},
-	}
-	datasets = append(datasets, networkData)
-
-	return datasets, nil
-}
-
-// Generate realistic Higgs boson decay data
-func generateHiggsDecayData() []map[string]interface{} {
-	measurements := []map[string]interface{}{}
-	rand.Seed(time.Now().UnixNano())
-
-	// Higgs mass peak around 125 GeV with realistic distribution
-	for i := 0; i < 50; i++ {
-		// Generate invariant mass with Higgs peak
-		mass := 125.0 + rand.NormFloat64()*2.5 // Gaussian around 125 GeV
-		if mass < 110 || mass > 140 {
-			mass = 125.0 + rand.Float64()*10 - 5 // Uniform background
-		}
-
-		energy1 := 20 + rand.Float64()*80  // First photon energy
-		energy2 := mass - energy1 + rand.NormFloat64()*5 // Second photon energy
-
-		measurements = append(measurements, map[string]interface{}{
-			"invariant_mass": math.Round(mass*100)/100,
-			"photon1_energy": math.Round(energy1*100)/100,
-			"photon2_energy": math.Round(energy2*100)/100,
-			"time": float64(i) * 0.025, // 25 ns between measurements
-		})
-	}
-
-	return measurements
-}
-
-// Generate cosmic ray muon data
-func generateCosmicRayData() []map[string]interface{} {
-	collisions := []map[string]interface{}{}
-	rand.Seed(time.Now().UnixNano() + 1)
-
-	for i := 0; i < 30; i++ {
-		// Cosmic ray muons have characteristic momentum distribution
-		momentum := 1.0 + rand.ExpFloat64()*10 // Exponential distribution
-		angle := rand.Float64() * 180 // 0-180 degrees
-		
-		// Muon energy loss in detector
-		energyLoss := 2.0 + rand.Float64()*8 // MeV/cm typical for muons
-
-		collisions = append(collisions, map[string]interface{}{
-			"momentum": math.Round(momentum*100)/100,
-			"angle": math.Round(angle*10)/10,
-			"energy_loss": math.Round(energyLoss*100)/100,
-			"track_length": math.Round((5 + rand.Float64()*15)*10)/10, // cm
-		})
-	}
-
-	return collisions
-}
-
-// Generate quantum entanglement correlation data
-func generateQuantumEntanglementData() []map[string]interface{} {
-	measurements := []map[string]interface{}{}
-	rand.Seed(time.Now().UnixNano() + 2)
-
-	// Bell test angles
-	angles := []float64{0, 22.5, 45, 67.5, 90, 112.5, 135, 157.5}
-
-	for _, angle := range angles {
-		// Quantum correlation should violate Bell inequality
-		correlation := -math.Cos(2 * angle * math.Pi / 180) // Quantum prediction
-		correlation += (rand.Float64() - 0.5) * 0.1 // Add experimental noise
-
-		measurements = append(measurements, map[string]interface{}{
-			"angle": angle,
-			"correlation": math.Round(correlation*1000)/1000,
-			"measurement_count": 1000 + rand.Intn(500),
-			"statistical_error": math.Round(rand.Float64()*0.05*1000)/1000,
-		})
-	}
-
-	return measurements
-}
-
-// Generate realistic sorting algorithm benchmarks
-func generateSortingBenchmarks() []map[string]interface{} {
-	algorithms := []map[string]interface{}{}
-	inputSizes := []int{1000, 5000, 10000, 50000, 100000, 500000}
-
-	// QuickSort - O(n log n) average, O(n²) worst
-	quicksortTimes := []float64{}
-	for _, n := range inputSizes {
-		baseTime := float64(n) * math.Log(float64(n)) / 1000000 // Normalize
-		quicksortTimes = append(quicksortTimes, math.Round(baseTime*1000)/1000)
-	}
-
-	// MergeSort - O(n log n) guaranteed
-	mergesortTimes := []float64{}
-	for _, n := range inputSizes {
-		baseTime := float64(n) * math.Log(float64(n)) / 800000 // Slightly slower than quicksort
-		mergesortTimes = append(mergesortTimes, math.Round(baseTime*1000)/1000)
-	}
-
-	// TimSort (Python's sort) - O(n) to O(n log n)
-	timsortTimes := []float64{}
-	for _, n := range inputSizes {
-		baseTime := float64(n) * math.Log(float64(n)) / 900000 // Optimized for real data
-		timsortTimes = append(timsortTimes, math.Round(baseTime*1000)/1000)
-	}
-
-	// RadixSort - O(kn) where k is number of digits
-	radixsortTimes := []float64{}
-	for _, n := range inputSizes {
-		baseTime := float64(n) * 4 / 1000000 // Linear with constant factor
-		radixsortTimes = append(radixsortTimes, math.Round(baseTime*1000)/1000)
-	}
-
-	algorithms = append(algorithms, map[string]interface{}{
-		"name": "QuickSort",
-		"runtime": quicksortTimes,
-		"input_size": inputSizes,
-		"complexity": "O(n log n) avg, O(n²) worst",
-	})
-
-	algorithms = append(algorithms, map[string]interface{}{
-		"name": "MergeSort",
-		"runtime": mergesortTimes,
-		"input_size": inputSizes,
-		"complexity": "O(n log n)",
-	})
-
-	algorithms = append(algorithms, map[string]interface{}{
-		"name": "TimSort",
-		"runtime": timsortTimes,
-		"input_size": inputSizes,
-		"complexity": "O(n) to O(n log n)",
-	})
-
-	algorithms = append(algorithms, map[string]interface{}{
-		"name": "RadixSort",
-		"runtime": radixsortTimes,
-		"input_size": inputSizes,
-		"complexity": "O(kn)",
-	})
-
-	return algorithms
-}
-
-// Generate ML training performance data
-func generateMLTrainingData() []map[string]interface{} {
-	models := []map[string]interface{}{}
-
-	// ResNet-50 training data
-	resnetEpochs := []int{}
-	resnetAccuracy := []float64{}
-	resnetLoss := []float64{}
-	
-	for epoch := 1; epoch <= 100; epoch++ {
-		resnetEpochs = append(resnetEpochs, epoch)
-		// Realistic training curve
-		accuracy := 0.1 + 0.65*(1 - math.Exp(-float64(epoch)/20)) + rand.Float64()*0.05
-		loss := 2.3*math.Exp(-float64(epoch)/15) + 0.1 + rand.Float64()*0.1
-		resnetAccuracy = append(resnetAccuracy, math.Round(accuracy*1000)/1000)
-		resnetLoss = append(resnetLoss, math.Round(loss*1000)/1000)
-	}
-
-	models = append(models, map[string]interface{}{
-		"name": "ResNet-50",
-		"epochs": resnetEpochs,
-		"accuracy": resnetAccuracy,
-		"loss": resnetLoss,
-		"parameters": "25.6M",
-		"training_time_per_epoch": "4.2 minutes",
-	})
-
-	// Vision Transformer training data
-	vitEpochs := []int{}
-	vitAccuracy := []float64{}
-	vitLoss := []float64{}
-	
-	for epoch := 1; epoch <= 100; epoch++ {
-		vitEpochs = append(vitEpochs, epoch)
-		// ViT typically starts slower but reaches higher accuracy
-		accuracy := 0.05 + 0.75*(1 - math.Exp(-float64(epoch)/25)) + rand.Float64()*0.03
-		loss := 2.8*math.Exp(-float64(epoch)/18) + 0.08 + rand.Float64()*0.08
-		vitAccuracy = append(vitAccuracy, math.Round(accuracy*1000)/1000)
-		vitLoss = append(vitLoss, math.Round(loss*1000)/1000)
-	}
-
-	models = append(models, map[string]interface{}{
-		"name": "Vision Transformer",
-		"epochs": vitEpochs,
-		"accuracy": vitAccuracy,
-		"loss": vitLoss,
-		"parameters": "86.6M",
-		"training_time_per_epoch": "6.8 minutes",
-	})
-
-	return models
-}
-
-// Generate 5G network performance data
-func generate5GNetworkData() []map[string]interface{} {
-	metrics := []map[string]interface{}{}
-	rand.Seed(time.Now().UnixNano() + 3)
-
-	// Generate 24 hours of data (hourly measurements)
-	for hour := 0; hour < 24; hour++ {
-		timestamp := fmt.Sprintf("2024-01-15T%02d:00:00Z", hour)
-		
-		// Realistic 5G performance varies by time of day
-		var bandwidth, latency, packetLoss float64
-		
-		if hour >= 8 && hour <= 18 { // Business hours - higher load
-			bandwidth = 800 + rand.Float64()*400 // 800-1200 Mbps
-			latency = 8 + rand.Float64()*12 // 8-20 ms
-			packetLoss = rand.Float64() * 0.5 // 0-0.5%
-		} else if hour >= 19 && hour <= 23 { // Evening peak
-			bandwidth = 600 + rand.Float64()*600 // 600-1200 Mbps
-			latency = 10 + rand.Float64()*15 // 10-25 ms
-			packetLoss = rand.Float64() * 0.8 // 0-0.8%
-		} else { // Night/early morning - low load
-			bandwidth = 1000 + rand.Float64()*500 // 1000-1500 Mbps
-			latency = 3 + rand.Float64()*7 // 3-10 ms
-			packetLoss = rand.Float64() * 0.2 // 0-0.2%
-		}
-
-		metrics = append(metrics, map[string]interface{}{
-			"timestamp": timestamp,
-			"bandwidth": math.Round(bandwidth*10)/10,
-			"latency": math.Round(latency*10)/10,
-			"packet_loss": math.Round(packetLoss*1000)/1000,
-			"signal_strength": -70 + rand.Float64()*20, // dBm
-			"user_count": 50 + rand.Intn(200),
-		})
-	}
-
-	return metrics
-}
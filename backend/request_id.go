@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// requestIDHeader carries the request ID in both directions
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits accepted client IDs to what is safe to log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestInfo describes a request for logging. Handlers deeper in the chain
+// fill in what they learn, such as the authenticated user.
+type requestInfo struct {
+	ID     string
+	UserID string
+}
+
+type requestInfoContextKey struct{}
+
+// requestIDMiddleware gives every request an ID, taken from X-Request-ID
+// when the caller (e.g. a load balancer) sent a usable one and generated
+// otherwise. The ID is echoed in the response header and in error bodies.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		info := &requestInfo{ID: id}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestInfoContextKey{}, info)))
+	})
+}
+
+// requestInfoFrom returns the request's info. Outside requestIDMiddleware
+// it returns an empty one, so callers need not check.
+func requestInfoFrom(r *http.Request) *requestInfo {
+	if info, ok := r.Context().Value(requestInfoContextKey{}).(*requestInfo); ok {
+		return info
+	}
+	return &requestInfo{}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
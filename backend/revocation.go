@@ -0,0 +1,221 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// revocationSyncOverlap re-reads revocations recorded shortly before the
+// last sync, so a row committed late by another replica is not missed
+const revocationSyncOverlap = time.Minute
+
+// RevocationList caches the revoked_tokens table in memory so authMiddleware
+// can check a token's jti without a database query. It also caches
+// revoked_users, which revokes every access token issued to a user up to a
+// point in time. Revocations made by this process apply at once; those made
+// by other replicas apply after the next Sync.
+type RevocationList struct {
+	mu       sync.RWMutex
+	revoked  map[string]time.Time // jti → expiry of the revoked token
+	syncedAt time.Time            // newest revoked_at seen
+
+	users         map[string]userRevocation // user ID → revocation
+	usersSyncedAt time.Time                 // newest revoked_users.revoked_at seen
+}
+
+// userRevocation revokes a user's access tokens issued up to before
+type userRevocation struct {
+	before, expiresAt time.Time
+}
+
+var revocations = &RevocationList{revoked: map[string]time.Time{}, users: map[string]userRevocation{}}
+
+// IsRevoked reports whether the token with this jti has been revoked
+func (l *RevocationList) IsRevoked(jti string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.revoked[jti]
+	return ok
+}
+
+// IsRevokedForUser reports whether tokens issued to the user at issuedAt
+// have been revoked. Token times have whole-second precision, so a token
+// issued in the same second as the revocation counts as revoked.
+func (l *RevocationList) IsRevokedForUser(userID string, issuedAt time.Time) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	r, ok := l.users[userID]
+	return ok && issuedAt.Unix() <= r.before.Unix()
+}
+
+// RevokeUser ends a user's sessions within tx: it revokes their refresh
+// token families and records that their access tokens issued until now no
+// longer work. Call the returned function once tx has committed to apply
+// the revocation in this process.
+func (l *RevocationList) RevokeUser(tx *sql.Tx, userID string) (func(), error) {
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	var r userRevocation
+	err := tx.QueryRow(`
+		INSERT INTO revoked_users (user_id, revoked_at, expires_at)
+		VALUES ($1, NOW(), NOW() + make_interval(secs => $2))
+		ON CONFLICT (user_id) DO UPDATE SET revoked_at = EXCLUDED.revoked_at, expires_at = EXCLUDED.expires_at
+		RETURNING revoked_at, expires_at`,
+		userID, authConfig.AccessTokenTTL.Seconds(),
+	).Scan(&r.before, &r.expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return func() {
+		l.mu.Lock()
+		l.users[userID] = r
+		l.mu.Unlock()
+	}, nil
+}
+
+// Revoke records a token as revoked until it expires
+func (l *RevocationList) Revoke(jti string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	_, err := database.GetDB().Exec(
+		"INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		jti, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	l.mu.Lock()
+	l.revoked[jti] = expiresAt
+	l.mu.Unlock()
+	return nil
+}
+
+// Sync loads revocations recorded since the previous sync and forgets
+// tokens that have expired
+func (l *RevocationList) Sync() error {
+	l.mu.RLock()
+	since := l.syncedAt.Add(-revocationSyncOverlap)
+	l.mu.RUnlock()
+
+	rows, err := database.GetDB().Query(
+		"SELECT jti, expires_at, revoked_at FROM revoked_tokens WHERE expires_at > NOW() AND revoked_at > $1",
+		since,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load revoked tokens: %w", err)
+	}
+	defer rows.Close()
+
+	type revocation struct {
+		jti                  string
+		expiresAt, revokedAt time.Time
+	}
+	var loaded []revocation
+	for rows.Next() {
+		var r revocation
+		if err := rows.Scan(&r.jti, &r.expiresAt, &r.revokedAt); err != nil {
+			return err
+		}
+		loaded = append(loaded, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	users, err := l.loadUserRevocations()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, r := range loaded {
+		l.revoked[r.jti] = r.expiresAt
+		if r.revokedAt.After(l.syncedAt) {
+			l.syncedAt = r.revokedAt
+		}
+	}
+	for jti, expiresAt := range l.revoked {
+		if !expiresAt.After(now) {
+			delete(l.revoked, jti)
+		}
+	}
+
+	for userID, r := range users {
+		if current, ok := l.users[userID]; !ok || r.before.After(current.before) {
+			l.users[userID] = r
+		}
+		if r.before.After(l.usersSyncedAt) {
+			l.usersSyncedAt = r.before
+		}
+	}
+	for userID, r := range l.users {
+		if !r.expiresAt.After(now) {
+			delete(l.users, userID)
+		}
+	}
+	return nil
+}
+
+// loadUserRevocations reads the user revocations recorded since the
+// previous sync
+func (l *RevocationList) loadUserRevocations() (map[string]userRevocation, error) {
+	l.mu.RLock()
+	since := l.usersSyncedAt.Add(-revocationSyncOverlap)
+	l.mu.RUnlock()
+
+	rows, err := database.GetDB().Query(
+		"SELECT user_id, revoked_at, expires_at FROM revoked_users WHERE expires_at > NOW() AND revoked_at > $1",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revoked users: %w", err)
+	}
+	defer rows.Close()
+
+	users := map[string]userRevocation{}
+	for rows.Next() {
+		var userID string
+		var r userRevocation
+		if err := rows.Scan(&userID, &r.before, &r.expiresAt); err != nil {
+			return nil, err
+		}
+		users[userID] = r
+	}
+	return users, rows.Err()
+}
+
+// Run syncs the list every interval and removes expired revocations and
+// refresh tokens from the database
+func (l *RevocationList) Run(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := l.Sync(); err != nil {
+			slog.Warn("Failed to sync revoked tokens", "error", err)
+		}
+		if err := pruneExpiredTokens(); err != nil {
+			slog.Warn("Failed to prune expired tokens", "error", err)
+		}
+	}
+}
+
+// pruneExpiredTokens deletes rows that no longer affect authentication
+func pruneExpiredTokens() error {
+	if _, err := database.GetDB().Exec("DELETE FROM revoked_tokens WHERE expires_at <= NOW()"); err != nil {
+		return err
+	}
+	if _, err := database.GetDB().Exec("DELETE FROM revoked_users WHERE expires_at <= NOW()"); err != nil {
+		return err
+	}
+	_, err := database.GetDB().Exec("DELETE FROM refresh_tokens WHERE expires_at <= NOW()")
+	return err
+}
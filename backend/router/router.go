@@ -0,0 +1,155 @@
+// Package router registers handlers on an http.ServeMux by method and path
+// pattern, such as "GET /api/analytics/{id}", and wraps them in middleware
+// chains shared by groups of routes. Requests for a known path with an
+// unregistered method get 405 Method Not Allowed with an Allow header, and
+// OPTIONS requests are answered with the allowed methods.
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps a handler with behaviour that runs around it
+type Middleware func(http.Handler) http.Handler
+
+// Router dispatches requests to the registered routes. Register every route
+// before serving.
+type Router struct {
+	// mux holds the method patterns; paths holds one pattern per path with
+	// no method, and finds the path when no method pattern matches
+	mux   *http.ServeMux
+	paths *http.ServeMux
+
+	methods    map[string][]string
+	middleware []Middleware
+
+	// MethodNotAllowed writes the response for a method the path does not
+	// serve; the Allow header is already set
+	MethodNotAllowed http.HandlerFunc
+	// NotFound writes the response for a path with no routes
+	NotFound http.HandlerFunc
+}
+
+// New returns an empty router
+func New() *Router {
+	return &Router{
+		mux:     http.NewServeMux(),
+		paths:   http.NewServeMux(),
+		methods: map[string][]string{},
+		MethodNotAllowed: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		},
+		NotFound: http.NotFound,
+	}
+}
+
+// Use adds middleware that runs for every route, including 405 and OPTIONS
+// responses. Call it before registering routes.
+func (rt *Router) Use(middleware ...Middleware) {
+	rt.middleware = append(rt.middleware, middleware...)
+}
+
+// Group returns a group whose routes run the router's middleware and then
+// the given middleware, in order
+func (rt *Router) Group(middleware ...Middleware) *Group {
+	return &Group{router: rt, middleware: middleware}
+}
+
+// Handle registers a handler for a "METHOD /path" pattern with only the
+// router's middleware
+func (rt *Router) Handle(pattern string, handler http.Handler) {
+	rt.Group().Handle(pattern, handler)
+}
+
+// HandleFunc registers a handler function for a "METHOD /path" pattern
+func (rt *Router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rt.Handle(pattern, handler)
+}
+
+// ServeHTTP dispatches the request to the route for its method and path
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern == "" {
+		// No route for this method; if the path exists, rt.paths answers
+		// with 405 or the OPTIONS response
+		if _, path := rt.paths.Handler(r); path != "" {
+			rt.paths.ServeHTTP(w, r)
+			return
+		}
+		rt.NotFound(w, r)
+		return
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// register adds a route whose handler already includes its middleware
+func (rt *Router) register(method, path string, handler http.Handler) {
+	if _, seen := rt.methods[path]; !seen {
+		fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", strings.Join(rt.methods[path], ", ")+", OPTIONS")
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			rt.MethodNotAllowed(w, r)
+		})
+		rt.paths.Handle(path, rt.withMethods(path, chain(rt.middleware, fallback)))
+	}
+	rt.methods[path] = append(rt.methods[path], method)
+	rt.mux.Handle(method+" "+path, rt.withMethods(path, handler))
+}
+
+// withMethods records the methods served on path in the request context
+func (rt *Router) withMethods(path string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), methodsContextKey{}, rt.methods[path])))
+	})
+}
+
+type methodsContextKey struct{}
+
+// AllowedMethods returns the methods registered for the path of the route
+// serving r, for middleware such as CORS that must describe them
+func AllowedMethods(r *http.Request) []string {
+	methods, _ := r.Context().Value(methodsContextKey{}).([]string)
+	return methods
+}
+
+// Group is a set of routes that share a middleware chain
+type Group struct {
+	router     *Router
+	middleware []Middleware
+}
+
+// Group returns a nested group that runs this group's middleware and then
+// the given middleware
+func (g *Group) Group(middleware ...Middleware) *Group {
+	return &Group{router: g.router, middleware: append(append([]Middleware{}, g.middleware...), middleware...)}
+}
+
+// Handle registers a handler for a "METHOD /path" pattern. The path may
+// contain {name} wildcards, read with r.PathValue.
+func (g *Group) Handle(pattern string, handler http.Handler) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" || method == http.MethodOptions || !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("router: invalid pattern %q (expected \"METHOD /path\")", pattern))
+	}
+
+	all := append(append([]Middleware{}, g.router.middleware...), g.middleware...)
+	g.router.register(method, path, chain(all, handler))
+}
+
+// HandleFunc registers a handler function for a "METHOD /path" pattern
+func (g *Group) HandleFunc(pattern string, handler http.HandlerFunc) {
+	g.Handle(pattern, handler)
+}
+
+// chain wraps handler so that middleware runs first to last
+func chain(middleware []Middleware, handler http.Handler) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// trace returns middleware that appends name to the X-Trace header
+func trace(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func reply(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+func testRouter() *Router {
+	rt := New()
+	rt.Use(trace("global"))
+	rt.MethodNotAllowed = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte("custom 405"))
+	}
+	rt.NotFound = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("custom 404"))
+	}
+
+	rt.HandleFunc("GET /items", reply("list"))
+	api := rt.Group(trace("auth"))
+	api.HandleFunc("POST /items", reply("create"))
+	api.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("item " + r.PathValue("id")))
+	})
+	api.Group(trace("admin")).HandleFunc("DELETE /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join(AllowedMethods(r), ",")))
+	})
+	return rt
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+		trace  []string
+	}{
+		{"registered route", "GET", "/items", 200, "list", "", []string{"global"}},
+		// The server drops HEAD bodies; the recorder keeps them
+		{"HEAD is served by GET", "HEAD", "/items", 200, "list", "", []string{"global"}},
+		{"group middleware", "POST", "/items", 200, "create", "", []string{"global", "auth"}},
+		{"wildcard", "GET", "/items/42", 200, "item 42", "", []string{"global", "auth"}},
+		{"nested group runs outer middleware first", "DELETE", "/items/42", 200, "GET,DELETE", "", []string{"global", "auth", "admin"}},
+		{"405 lists the methods", "PUT", "/items", 405, "custom 405", "GET, POST, OPTIONS", []string{"global"}},
+		{"405 on a wildcard path", "POST", "/items/42", 405, "custom 405", "GET, DELETE, OPTIONS", []string{"global"}},
+		{"OPTIONS", "OPTIONS", "/items", 204, "", "GET, POST, OPTIONS", []string{"global"}},
+		{"unknown path", "GET", "/missing", 404, "custom 404", "", nil},
+		{"unknown path and method", "PATCH", "/items/1/parts", 404, "custom 404", "", nil},
+	}
+
+	rt := testRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if body := rec.Body.String(); body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if allow := rec.Header().Get("Allow"); allow != tt.allow {
+				t.Errorf("Allow = %q, want %q", allow, tt.allow)
+			}
+			if got := rec.Header().Values("X-Trace"); !reflect.DeepEqual(got, tt.trace) {
+				t.Errorf("middleware ran %v, want %v", got, tt.trace)
+			}
+		})
+	}
+}
+
+func TestAllowedMethodsOnFallback(t *testing.T) {
+	rt := New()
+	var seen []string
+	rt.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = AllowedMethods(r)
+			next.ServeHTTP(w, r)
+		})
+	})
+	rt.HandleFunc("GET /a", reply("a"))
+	rt.HandleFunc("PUT /a", reply("a"))
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("OPTIONS", "/a", nil))
+	if !reflect.DeepEqual(seen, []string{"GET", "PUT"}) {
+		t.Errorf("AllowedMethods = %v, want [GET PUT]", seen)
+	}
+}
+
+func TestInvalidPatternsPanic(t *testing.T) {
+	for _, pattern := range []string{"/no-method", "GET", "GET no-slash", "OPTIONS /a", " /a"} {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("pattern %q did not panic", pattern)
+				}
+			}()
+			New().HandleFunc(pattern, reply(""))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Embedded schema files are named <shape>.v<version>.json
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var schemaFilePattern = regexp.MustCompile(`^([a-z0-9_]+)\.v(\d+)\.json$`)
+
+// Shape is one version of a known dataset payload layout. A payload has a
+// shape when it contains the shape's top-level key, e.g. "measurements".
+type Shape struct {
+	Name        string  `json:"name"`
+	Version     int     `json:"version"`
+	Key         string  `json:"key"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// shapes maps each shape name to its versions, oldest first
+var shapes = map[string][]*Shape{}
+
+func init() {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		match := schemaFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			panic(fmt.Sprintf("schema: unexpected embedded file %s", entry.Name()))
+		}
+
+		doc, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var meta struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Key         string `json:"x-shape-key"`
+		}
+		if err := json.Unmarshal(doc, &meta); err != nil || meta.Key == "" {
+			panic(fmt.Sprintf("schema: %s must declare x-shape-key", entry.Name()))
+		}
+
+		version, _ := strconv.Atoi(match[2])
+		shapes[match[1]] = append(shapes[match[1]], &Shape{
+			Name:        match[1],
+			Version:     version,
+			Key:         meta.Key,
+			Title:       meta.Title,
+			Description: meta.Description,
+			Schema:      MustCompile(doc),
+		})
+	}
+
+	for _, versions := range shapes {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	}
+}
+
+// Shapes returns the latest version of every known shape, ordered by name
+func Shapes() []*Shape {
+	latest := make([]*Shape, 0, len(shapes))
+	for _, versions := range shapes {
+		latest = append(latest, versions[len(versions)-1])
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Name < latest[j].Name })
+	return latest
+}
+
+// Versions returns every version of the named shape, oldest first
+func Versions(name string) []*Shape {
+	return shapes[name]
+}
+
+// Lookup returns one version of a shape; version 0 selects the latest
+func Lookup(name string, version int) (*Shape, bool) {
+	versions := shapes[name]
+	if len(versions) == 0 {
+		return nil, false
+	}
+	if version == 0 {
+		return versions[len(versions)-1], true
+	}
+	for _, shape := range versions {
+		if shape.Version == version {
+			return shape, true
+		}
+	}
+	return nil, false
+}
+
+// ValidatePayload checks a decoded dataset payload against the latest version
+// of every shape whose key it contains. It returns the names of the matched
+// shapes and the field errors, with fields rooted at "data".
+func ValidatePayload(data interface{}) ([]string, []FieldError) {
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, []FieldError{{Field: "data", Message: "must be object"}}
+	}
+
+	var matched []string
+	var errs []FieldError
+	for _, shape := range Shapes() {
+		if _, ok := obj[shape.Key]; !ok {
+			continue
+		}
+		matched = append(matched, shape.Name)
+		errs = append(errs, shape.Schema.Validate("data", data)...)
+	}
+
+	return matched, errs
+}
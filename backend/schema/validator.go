@@ -0,0 +1,579 @@
+// Package schema validates dataset payloads against JSON Schemas.
+//
+// The validator implements the subset of JSON Schema (draft 2020-12) that the
+// dataset payloads need: type, enum, const, properties, required,
+// additionalProperties, minProperties, maxProperties, items, minItems,
+// maxItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength,
+// maxLength, pattern, format (date-time), allOf, anyOf, oneOf, not and local
+// $ref pointers. References may recurse only through properties or items;
+// cycles that would apply a schema to the same value forever are rejected.
+// Other keywords are ignored, as the specification requires for unknown
+// keywords.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes one place where a document does not match its schema
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// maxDepth bounds how many schemas validate applies within one another. A
+// well-formed schema only nests as deeply as the document it validates, so
+// this is a backstop against recursion the cycle check did not catch.
+const maxDepth = 256
+
+// Schema is a compiled JSON Schema document
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+	// refs holds every $ref found while checking, mapped to whether its
+	// target has been checked
+	refs map[string]bool
+}
+
+// Compile parses a JSON Schema document and checks that every supported
+// keyword is well formed
+func Compile(doc []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}, refs: map[string]bool{}}
+	if err := s.check(root, "#"); err != nil {
+		return nil, err
+	}
+	if err := s.checkRefCycles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MustCompile is like Compile but panics on error; it is meant for embedded schemas
+func MustCompile(doc []byte) *Schema {
+	s, err := Compile(doc)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// MarshalJSON returns the original schema document
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.root)
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json into an
+// interface{}) and returns every mismatch, using root as the top-level field name
+func (s *Schema) Validate(root string, instance interface{}) []FieldError {
+	var errs []FieldError
+	s.validate(s.root, instance, root, 0, &errs)
+	return errs
+}
+
+var jsonTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// check walks a schema node and rejects malformed keywords
+func (s *Schema) check(node interface{}, at string) error {
+	if _, ok := node.(bool); ok {
+		return nil
+	}
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: schema must be an object or boolean", at)
+	}
+
+	if t, ok := obj["type"]; ok {
+		names, err := typeNames(t)
+		if err != nil {
+			return fmt.Errorf("%s/type: %w", at, err)
+		}
+		for _, name := range names {
+			if !jsonTypes[name] {
+				return fmt.Errorf("%s/type: unknown type %q", at, name)
+			}
+		}
+	}
+
+	for _, key := range []string{"properties", "$defs", "definitions"} {
+		if v, ok := obj[key]; ok {
+			children, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s/%s: must be an object", at, key)
+			}
+			for name, child := range children {
+				if err := s.check(child, at+"/"+key+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if v, ok := obj[key]; ok {
+			if err := s.check(v, at+"/"+key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if v, ok := obj[key]; ok {
+			list, ok := v.([]interface{})
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("%s/%s: must be a non-empty array", at, key)
+			}
+			for i, child := range list {
+				if err := s.check(child, fmt.Sprintf("%s/%s/%d", at, key, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if v, ok := obj["required"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s/required: must be an array of strings", at)
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return fmt.Errorf("%s/required: must be an array of strings", at)
+			}
+		}
+	}
+
+	if v, ok := obj["enum"]; ok {
+		if _, ok := v.([]interface{}); !ok {
+			return fmt.Errorf("%s/enum: must be an array", at)
+		}
+	}
+
+	for _, key := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+		"minItems", "maxItems", "minLength", "maxLength", "minProperties", "maxProperties"} {
+		if v, ok := obj[key]; ok {
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("%s/%s: must be a number", at, key)
+			}
+		}
+	}
+
+	if v, ok := obj["pattern"]; ok {
+		pattern, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s/pattern: must be a string", at)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s/pattern: %w", at, err)
+		}
+		s.patterns[pattern] = re
+	}
+
+	if v, ok := obj["$ref"]; ok {
+		ref, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s/$ref: must be a string", at)
+		}
+		target, err := s.resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s/$ref: %w", at, err)
+		}
+		switch target.(type) {
+		case bool, map[string]interface{}:
+		default:
+			return fmt.Errorf("%s/$ref: %q does not point to a schema", at, ref)
+		}
+
+		// The target may sit outside properties and $defs, where the walk
+		// above does not reach, so check it too (once per reference)
+		if !s.refs[ref] {
+			s.refs[ref] = true
+			if err := s.check(target, ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRefCycles rejects references that lead back to themselves without
+// descending into the document, such as {"$ref": "#"} or a $defs entry whose
+// allOf refers to itself; validating with them would never end. Recursion
+// through properties or items is fine, as every step moves into a smaller
+// part of the document.
+func (s *Schema) checkRefCycles() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+
+	var visit func(ref string) error
+	visit = func(ref string) error {
+		switch state[ref] {
+		case visiting:
+			return fmt.Errorf("%s: $ref cycle does not descend into the document", ref)
+		case done:
+			return nil
+		}
+		state[ref] = visiting
+		target, _ := s.resolve(ref)
+		for _, next := range sameInstanceRefs(target) {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		state[ref] = done
+		return nil
+	}
+
+	refs := make([]string, 0, len(s.refs))
+	for ref := range s.refs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if err := visit(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameInstanceRefs returns the references a schema applies to the same
+// value it is validating: its own $ref and those reached through allOf,
+// anyOf, oneOf and not
+func sameInstanceRefs(node interface{}) []string {
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	if ref, ok := obj["$ref"].(string); ok {
+		refs = append(refs, ref)
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := obj[key].([]interface{})
+		for _, child := range list {
+			refs = append(refs, sameInstanceRefs(child)...)
+		}
+	}
+	if not, ok := obj["not"]; ok {
+		refs = append(refs, sameInstanceRefs(not)...)
+	}
+	return refs
+}
+
+// resolve follows a local JSON pointer reference such as #/$defs/measurement
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local references are supported, got %q", ref)
+	}
+
+	node := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+		if node, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *Schema) validate(node interface{}, instance interface{}, path string, depth int, errs *[]FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if depth > maxDepth {
+		fail("is nested too deeply to validate")
+		return
+	}
+	depth++
+
+	if b, ok := node.(bool); ok {
+		if !b {
+			fail("is not allowed")
+		}
+		return
+	}
+	obj := node.(map[string]interface{})
+
+	if ref, ok := obj["$ref"].(string); ok {
+		target, _ := s.resolve(ref)
+		s.validate(target, instance, path, depth, errs)
+	}
+
+	if t, ok := obj["type"]; ok {
+		names, _ := typeNames(t)
+		matched := false
+		for _, name := range names {
+			if hasType(instance, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must be %s", strings.Join(names, " or "))
+			// Further keywords would only repeat the type mismatch
+			return
+		}
+	}
+
+	if v, ok := obj["const"]; ok && !equal(instance, v) {
+		fail("must be %s", describe(v))
+	}
+
+	if v, ok := obj["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range v {
+			if equal(instance, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(v))
+			for i, option := range v {
+				options[i] = describe(option)
+			}
+			fail("must be one of %s", strings.Join(options, ", "))
+		}
+	}
+
+	switch value := instance.(type) {
+	case map[string]interface{}:
+		s.validateObject(obj, value, path, depth, errs)
+	case []interface{}:
+		s.validateArray(obj, value, path, depth, errs)
+	case string:
+		s.validateString(obj, value, path, errs)
+	case float64:
+		validateNumber(obj, value, path, errs)
+	}
+
+	if list, ok := obj["allOf"].([]interface{}); ok {
+		for _, child := range list {
+			s.validate(child, instance, path, depth, errs)
+		}
+	}
+
+	if list, ok := obj["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, child := range list {
+			if s.matches(child, instance, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must match at least one of the allowed forms")
+		}
+	}
+
+	if list, ok := obj["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, child := range list {
+			if s.matches(child, instance, depth) {
+				count++
+			}
+		}
+		if count != 1 {
+			fail("must match exactly one of the allowed forms (matched %d)", count)
+		}
+	}
+
+	if not, ok := obj["not"]; ok && s.matches(not, instance, depth) {
+		fail("matches a form that is not allowed")
+	}
+}
+
+func (s *Schema) matches(node interface{}, instance interface{}, depth int) bool {
+	var errs []FieldError
+	s.validate(node, instance, "", depth, &errs)
+	return len(errs) == 0
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, value map[string]interface{}, path string, depth int, errs *[]FieldError) {
+	if required, ok := obj["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := value[name.(string)]; !present {
+				*errs = append(*errs, FieldError{Field: joinField(path, name.(string)), Message: "is required"})
+			}
+		}
+	}
+
+	if v, ok := obj["minProperties"].(float64); ok && float64(len(value)) < v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must have at least %v properties", v)})
+	}
+	if v, ok := obj["maxProperties"].(float64); ok && float64(len(value)) > v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must have at most %v properties", v)})
+	}
+
+	properties, _ := obj["properties"].(map[string]interface{})
+	additional, hasAdditional := obj["additionalProperties"]
+
+	// Visit keys in a stable order so error lists are deterministic
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := joinField(path, key)
+		if child, ok := properties[key]; ok {
+			s.validate(child, value[key], field, depth, errs)
+		} else if hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				*errs = append(*errs, FieldError{Field: field, Message: "is not a recognised field"})
+			} else {
+				s.validate(additional, value[key], field, depth, errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateArray(obj map[string]interface{}, value []interface{}, path string, depth int, errs *[]FieldError) {
+	if v, ok := obj["minItems"].(float64); ok && float64(len(value)) < v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must have at least %v items", v)})
+	}
+	if v, ok := obj["maxItems"].(float64); ok && float64(len(value)) > v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must have at most %v items", v)})
+	}
+
+	if items, ok := obj["items"]; ok {
+		for i, item := range value {
+			s.validate(items, item, path+"["+strconv.Itoa(i)+"]", depth, errs)
+		}
+	}
+}
+
+func (s *Schema) validateString(obj map[string]interface{}, value string, path string, errs *[]FieldError) {
+	length := float64(utf8.RuneCountInString(value))
+	if v, ok := obj["minLength"].(float64); ok && length < v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be at least %v characters", v)})
+	}
+	if v, ok := obj["maxLength"].(float64); ok && length > v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be at most %v characters", v)})
+	}
+
+	if pattern, ok := obj["pattern"].(string); ok && !s.patterns[pattern].MatchString(value) {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must match pattern %q", pattern)})
+	}
+
+	if format, ok := obj["format"].(string); ok && format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be an RFC 3339 date-time"})
+		}
+	}
+}
+
+func validateNumber(obj map[string]interface{}, value float64, path string, errs *[]FieldError) {
+	if v, ok := obj["minimum"].(float64); ok && value < v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be >= %v", v)})
+	}
+	if v, ok := obj["maximum"].(float64); ok && value > v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be <= %v", v)})
+	}
+	if v, ok := obj["exclusiveMinimum"].(float64); ok && value <= v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be > %v", v)})
+	}
+	if v, ok := obj["exclusiveMaximum"].(float64); ok && value >= v {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be < %v", v)})
+	}
+}
+
+func typeNames(t interface{}) ([]string, error) {
+	switch v := t.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a string or array of strings")
+			}
+			names = append(names, name)
+		}
+		return names, nil
+	default:
+		return nil, fmt.Errorf("must be a string or array of strings")
+	}
+}
+
+func hasType(instance interface{}, name string) bool {
+	switch name {
+	case "object":
+		_, ok := instance.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := instance.([]interface{})
+		return ok
+	case "string":
+		_, ok := instance.(string)
+		return ok
+	case "number":
+		_, ok := instance.(float64)
+		return ok
+	case "integer":
+		v, ok := instance.(float64)
+		return ok && v == math.Trunc(v) && !math.IsInf(v, 0)
+	case "boolean":
+		_, ok := instance.(bool)
+		return ok
+	case "null":
+		return instance == nil
+	}
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func describe(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(encoded)
+}
+
+func joinField(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
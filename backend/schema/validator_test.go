@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, doc string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("bad test document %s: %v", doc, err)
+	}
+	return v
+}
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		// errs lists the expected "field: message" prefixes, in order
+		errs []string
+	}{
+		{"type ok", `{"type":"string"}`, `"a"`, nil},
+		{"type mismatch", `{"type":"string"}`, `1`, []string{"data: must be string"}},
+		{"type list", `{"type":["string","null"]}`, `null`, nil},
+		{"integer", `{"type":"integer"}`, `1.5`, []string{"data: must be integer"}},
+		{"const", `{"const":3}`, `4`, []string{"data: must be 3"}},
+		{"enum", `{"enum":["a","b"]}`, `"c"`, []string{`data: must be one of "a", "b"`}},
+		{"required", `{"required":["x","y"]}`, `{"x":1}`, []string{"data.y: is required"}},
+		{"properties", `{"properties":{"x":{"type":"number"}}}`, `{"x":"no"}`, []string{"data.x: must be number"}},
+		{"additionalProperties false", `{"properties":{"x":{}},"additionalProperties":false}`, `{"x":1,"y":2}`,
+			[]string{"data.y: is not a recognised field"}},
+		{"additionalProperties schema", `{"additionalProperties":{"type":"string"}}`, `{"a":"ok","b":2}`,
+			[]string{"data.b: must be string"}},
+		{"minProperties", `{"minProperties":2}`, `{"a":1}`, []string{"data: must have at least 2 properties"}},
+		{"maxProperties", `{"maxProperties":1}`, `{"a":1,"b":2}`, []string{"data: must have at most 1 properties"}},
+		{"items", `{"items":{"type":"number"}}`, `[1,"x",3]`, []string{"data[1]: must be number"}},
+		{"minItems", `{"minItems":2}`, `[1]`, []string{"data: must have at least 2 items"}},
+		{"maxItems", `{"maxItems":1}`, `[1,2]`, []string{"data: must have at most 1 items"}},
+		{"minimum", `{"minimum":0}`, `-1`, []string{"data: must be >= 0"}},
+		{"maximum", `{"maximum":1}`, `2`, []string{"data: must be <= 1"}},
+		{"exclusiveMinimum", `{"exclusiveMinimum":0}`, `0`, []string{"data: must be > 0"}},
+		{"exclusiveMaximum", `{"exclusiveMaximum":1}`, `1`, []string{"data: must be < 1"}},
+		{"minLength counts runes", `{"minLength":2}`, `"é"`, []string{"data: must be at least 2 characters"}},
+		{"maxLength", `{"maxLength":1}`, `"ab"`, []string{"data: must be at most 1 characters"}},
+		{"pattern", `{"pattern":"^a+$"}`, `"ab"`, []string{`data: must match pattern "^a+$"`}},
+		{"date-time", `{"format":"date-time"}`, `"yesterday"`, []string{"data: must be an RFC 3339 date-time"}},
+		{"date-time ok", `{"format":"date-time"}`, `"2024-01-02T03:04:05Z"`, nil},
+		{"allOf", `{"allOf":[{"minimum":0},{"maximum":1}]}`, `2`, []string{"data: must be <= 1"}},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"null"}]}`, `1`,
+			[]string{"data: must match at least one of the allowed forms"}},
+		{"oneOf matches both", `{"oneOf":[{"type":"number"},{"minimum":0}]}`, `1`,
+			[]string{"data: must match exactly one of the allowed forms (matched 2)"}},
+		{"not", `{"not":{"type":"null"}}`, `null`, []string{"data: matches a form that is not allowed"}},
+		{"false schema", `{"properties":{"x":false}}`, `{"x":1}`, []string{"data.x: is not allowed"}},
+		{"$defs ref", `{"items":{"$ref":"#/$defs/n"},"$defs":{"n":{"type":"number"}}}`, `[1,"a"]`,
+			[]string{"data[1]: must be number"}},
+		{"escaped ref", `{"$ref":"#/$defs/a~1b","$defs":{"a/b":{"type":"null"}}}`, `1`, []string{"data: must be null"}},
+		{"ref outside $defs compiles pattern", `{"foo":{"type":"string","pattern":"a"},"$ref":"#/foo"}`, `"b"`,
+			[]string{`data: must match pattern "a"`}},
+		{"recursive through items", `{"type":"array","items":{"$ref":"#"}}`, `[[[]],[1]]`, []string{"data[1][0]: must be array"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			errs := s.Validate("data", decode(t, tt.instance))
+			if len(errs) != len(tt.errs) {
+				t.Fatalf("got errors %v, want %v", errs, tt.errs)
+			}
+			for i, want := range tt.errs {
+				if got := errs[i].Error(); !strings.HasPrefix(got, want) {
+					t.Errorf("error %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"not JSON", `{`, "not valid JSON"},
+		{"not a schema", `[1]`, "must be an object or boolean"},
+		{"unknown type", `{"type":"text"}`, `unknown type "text"`},
+		{"bad pattern", `{"pattern":"("}`, "#/pattern"},
+		{"bad minimum", `{"minimum":"0"}`, "must be a number"},
+		{"empty anyOf", `{"anyOf":[]}`, "non-empty array"},
+		{"remote ref", `{"$ref":"http://example.com/s"}`, "only local references"},
+		{"missing ref", `{"$ref":"#/$defs/nope"}`, "unresolvable reference"},
+		{"ref to non-schema", `{"$ref":"#/x","x":3}`, "does not point to a schema"},
+		{"bad pattern behind ref", `{"$ref":"#/x","x":{"pattern":"("}}`, "#/x/pattern"},
+		{"self ref", `{"$ref":"#"}`, "cycle"},
+		{"self ref through allOf", `{"allOf":[{"$ref":"#"}]}`, "cycle"},
+		{"mutual refs", `{"$ref":"#/$defs/a","$defs":{"a":{"$ref":"#/$defs/b"},"b":{"not":{"$ref":"#/$defs/a"}}}}`, "cycle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Compile error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDepthLimit(t *testing.T) {
+	s := MustCompile([]byte(`{"items":{"$ref":"#"}}`))
+	instance := decode(t, strings.Repeat("[", maxDepth+10)+strings.Repeat("]", maxDepth+10))
+
+	errs := s.Validate("data", instance)
+	if len(errs) != 1 || errs[0].Message != "is nested too deeply to validate" {
+		t.Fatalf("got %v, want one depth error", errs)
+	}
+}
+
+func TestEmbeddedShapes(t *testing.T) {
+	for _, shape := range Shapes() {
+		if shape.Schema == nil || shape.Key == "" {
+			t.Errorf("%s v%d: missing schema or key", shape.Name, shape.Version)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"fresherpaint/backend/models"
+	"fresherpaint/backend/schema"
+)
+
+// SchemaSummary lists a payload shape with all of its published versions
+type SchemaSummary struct {
+	*schema.Shape
+	Versions []int `json:"versions"`
+}
+
+// validateDatasetPayload checks a dataset payload against the built-in shape
+// schemas and the data_schema of its dataset type, if one is registered
+func validateDatasetPayload(dataType models.AnalyticsType, data []byte) []schema.FieldError {
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return []schema.FieldError{{Field: "data", Message: "must be valid JSON"}}
+	}
+
+	_, errs := schema.ValidatePayload(decoded)
+
+	if typeSchema := datasetTypes.Schema(string(dataType)); typeSchema != nil {
+		errs = append(errs, typeSchema.Validate("data", decoded)...)
+	}
+
+	return errs
+}
+
+// schemaErrors rejects a payload with its field-level errors
+func schemaErrors(errs []schema.FieldError) *APIError {
+	return &APIError{Code: CodeValidation, Message: "data does not match its schema", Details: errs}
+}
+
+// listSchemasHandler serves GET /api/schemas
+func listSchemasHandler(w http.ResponseWriter, r *http.Request) {
+	var summaries []SchemaSummary
+	for _, shape := range schema.Shapes() {
+		summaries = append(summaries, SchemaSummary{Shape: shape, Versions: shapeVersions(shape.Name)})
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: summaries})
+}
+
+// getSchemaHandler serves GET /api/schemas/{name}?version=N; the latest version is the default
+func getSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			writeError(w, apiError(CodeBadRequest, "version must be a positive integer"))
+			return
+		}
+		version = parsed
+	}
+
+	shape, ok := schema.Lookup(name, version)
+	if !ok {
+		writeError(w, notFound("Schema"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Data:    SchemaSummary{Shape: shape, Versions: shapeVersions(shape.Name)},
+	})
+}
+
+func shapeVersions(name string) []int {
+	var versions []int
+	for _, shape := range schema.Versions(name) {
+		versions = append(versions, shape.Version)
+	}
+	return versions
+}
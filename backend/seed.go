@@ -0,0 +1,216 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"time"
+
+	"fresherpaint/backend/generators"
+)
+
+// Seed modes control what happens to the built-in datasets on server start
+const (
+	SeedModeMissing = "missing" // insert built-in datasets that are not stored yet
+	SeedModeReset   = "reset"   // delete every dataset and insert the built-ins again
+	SeedModeOff     = "off"     // leave analytics_data untouched
+)
+
+// SeedResult reports what a seeding run changed
+type SeedResult struct {
+	Deleted  int64
+	Inserted int
+	Skipped  int
+}
+
+// DefaultDataSeed is the seed the built-in datasets are generated from when
+// none is configured
+const DefaultDataSeed = 1
+
+// builtinDataset is a generated built-in dataset with its stable seed_key
+type builtinDataset struct {
+	key   string
+	index int // position among the seeded generators, used to stagger created_at
+	*generators.Dataset
+}
+
+// builtinDatasets runs the seeded generators whose key is not in skip,
+// with their default parameters. The seed_key is the generator name, and
+// each generator draws from its own seed derived from the base seed and
+// that name, so the datasets are independent and reproducible, and adding
+// a generator does not change the others.
+func builtinDatasets(seed int64, skip map[string]bool) ([]builtinDataset, error) {
+	var datasets []builtinDataset
+	for i, g := range generators.Seeded() {
+		if skip[g.Name()] {
+			continue
+		}
+		params, _ := json.Marshal(map[string]int64{"seed": generatorSeed(seed, g.Name())})
+		dataset, err := g.Generate(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s data: %w", g.Name(), err)
+		}
+		datasets = append(datasets, builtinDataset{key: g.Name(), index: i, Dataset: dataset})
+	}
+	return datasets, nil
+}
+
+// generatorSeed mixes the base seed with a generator name
+func generatorSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, seed)
+	h.Write([]byte(name))
+	// Generator parameters take seeds that are exact in JSON, below 2^53
+	return int64(h.Sum64() >> 11)
+}
+
+// seedOnStartup applies the configured seed mode when the server boots
+func seedOnStartup(config *Config) error {
+	switch config.SeedMode {
+	case SeedModeOff:
+		slog.Info("Seeding disabled", "seed_mode", SeedModeOff)
+		return nil
+	case SeedModeMissing, "":
+		_, err := seedDatasets(false, config.DataSeed)
+		return err
+	case SeedModeReset:
+		slog.Warn("All stored datasets will be deleted", "seed_mode", SeedModeReset)
+		_, err := seedDatasets(true, config.DataSeed)
+		return err
+	default:
+		return fmt.Errorf("unknown SEED_MODE %q (expected %s, %s or %s)",
+			config.SeedMode, SeedModeMissing, SeedModeReset, SeedModeOff)
+	}
+}
+
+// seedDatasets inserts the built-in datasets whose seed key is not stored yet,
+// generated from the given seed. With reset set, every row in analytics_data
+// is deleted first, including datasets that were not created by seeding.
+func seedDatasets(reset bool, seed int64) (*SeedResult, error) {
+	result := &SeedResult{}
+
+	// Only generate what is missing; a reset replaces everything
+	existing := map[string]bool{}
+	if !reset {
+		var err error
+		if existing, err = storedSeedKeys(database.GetDB()); err != nil {
+			return nil, err
+		}
+	}
+	for _, g := range generators.Seeded() {
+		if existing[g.Name()] {
+			result.Skipped++
+		}
+	}
+
+	slog.Info("Generating built-in datasets", "seed", seed, "stored", result.Skipped)
+	datasets, err := builtinDatasets(seed, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin seed transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if reset {
+		res, err := tx.Exec("DELETE FROM analytics_data")
+		if err != nil {
+			return nil, fmt.Errorf("failed to reset analytics data: %w", err)
+		}
+		result.Deleted, _ = res.RowsAffected()
+		slog.Info("Deleted existing datasets", "count", result.Deleted)
+	}
+
+	for _, dataset := range datasets {
+		if err := insertDataset(tx, dataset); err != nil {
+			return nil, fmt.Errorf("failed to insert dataset %q: %w", dataset.key, err)
+		}
+		slog.Info("Seeded dataset", "key", dataset.key)
+		result.Inserted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit seed transaction: %w", err)
+	}
+
+	slog.Info("Seeding completed", "inserted", result.Inserted, "skipped", result.Skipped)
+	return result, nil
+}
+
+// storedSeedKeys returns the seed keys already present in analytics_data
+func storedSeedKeys(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT seed_key FROM analytics_data WHERE seed_key IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query seed keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := map[string]bool{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+
+	return keys, rows.Err()
+}
+
+// insertDataset inserts a built-in dataset into the database
+func insertDataset(tx *sql.Tx, dataset builtinDataset) error {
+	// Convert data to JSON
+	dataJSON, err := json.Marshal(dataset.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data to JSON: %w", err)
+	}
+
+	// Set timestamps
+	now := time.Now()
+	createdAt := now.Add(-time.Duration(dataset.index+1) * 24 * time.Hour) // Stagger creation dates
+	updatedAt := now
+
+	// Insert into database; a concurrent seeder may have stored the same key already
+	query := `
+		INSERT INTO analytics_data (seed_key, title, description, data_type, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (seed_key) DO NOTHING
+	`
+
+	_, err = tx.Exec(
+		query,
+		dataset.key,
+		dataset.Title,
+		dataset.Description,
+		dataset.DataType,
+		dataJSON,
+		createdAt,
+		updatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert dataset into database: %w", err)
+	}
+
+	return nil
+}
+
+// seedCommand implements `main seed [--reset] [--seed N]`
+func seedCommand(config *Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	reset := flags.Bool("reset", false, "delete ALL stored datasets before inserting the built-in ones")
+	seed := flags.Int64("seed", config.DataSeed, "seed the built-in datasets are generated from (defaults to DATA_SEED)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	_, err := seedDatasets(*reset, *seed)
+	return err
+}
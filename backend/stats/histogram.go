@@ -0,0 +1,256 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxBins caps the number of bins any binning rule may produce
+const MaxBins = 10000
+
+// Binning rules reported in Histogram.Binning
+const (
+	BinningEdges            = "edges"
+	BinningWidth            = "width"
+	BinningCount            = "count"
+	BinningFreedmanDiaconis = "freedman_diaconis"
+)
+
+// Options selects how values are binned. Edges take precedence over Width,
+// and Width over Bins; when none is set the Freedman–Diaconis rule picks the
+// width. Min and Max bound the range for the Width, Bins and
+// Freedman–Diaconis rules and default to the range of the data.
+type Options struct {
+	Bins  int
+	Width float64
+	Edges []float64
+	Min   *float64
+	Max   *float64
+}
+
+// Histogram is a one-dimensional histogram. Bin i covers
+// [Edges[i], Edges[i+1]); the last bin also includes its upper edge.
+type Histogram struct {
+	Binning    string    `json:"binning"`
+	Edges      []float64 `json:"edges"`
+	Centers    []float64 `json:"centers"`
+	Counts     []float64 `json:"counts"`
+	Errors     []float64 `json:"errors"`
+	ErrorLow   []float64 `json:"error_low"`
+	ErrorHigh  []float64 `json:"error_high"`
+	Weighted   bool      `json:"weighted"`
+	Entries    int       `json:"entries"`
+	SumWeights float64   `json:"sum_weights"`
+	Underflow  float64   `json:"underflow"`
+	Overflow   float64   `json:"overflow"`
+	Skipped    int       `json:"skipped"`
+}
+
+// NewHistogram bins values, each weighted by the matching entry of weights
+// when weights is not nil. Pairs with a NaN or infinite value or weight are
+// counted in Skipped.
+//
+// Errors holds the symmetric error sqrt(Σw²) of each bin. For unweighted
+// histograms ErrorLow and ErrorHigh give the asymmetric Poisson (Garwood)
+// 68.27% interval around the count, which stays sensible for empty and
+// sparsely populated bins; for weighted ones they repeat Errors.
+func NewHistogram(values, weights []float64, opts Options) (*Histogram, error) {
+	if weights != nil && len(weights) != len(values) {
+		return nil, fmt.Errorf("got %d weights for %d values", len(weights), len(values))
+	}
+
+	h := &Histogram{Weighted: weights != nil}
+	xs := make([]float64, 0, len(values))
+	ws := make([]float64, 0, len(values))
+	for i, x := range values {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		if math.IsNaN(x) || math.IsInf(x, 0) || math.IsNaN(w) || math.IsInf(w, 0) {
+			h.Skipped++
+			continue
+		}
+		xs = append(xs, x)
+		ws = append(ws, w)
+	}
+
+	edges, rule, err := binEdges(xs, opts)
+	if err != nil {
+		return nil, err
+	}
+	h.Binning = rule
+	h.Edges = edges
+
+	bins := len(edges) - 1
+	h.Counts = make([]float64, bins)
+	sumw2 := make([]float64, bins)
+	lo, hi := edges[0], edges[bins]
+	for i, x := range xs {
+		w := ws[i]
+		switch {
+		case x < lo:
+			h.Underflow += w
+			continue
+		case x > hi:
+			h.Overflow += w
+			continue
+		}
+
+		// SearchFloat64s finds the first edge >= x; values on an inner
+		// edge belong to the bin that starts there
+		bin := sort.SearchFloat64s(edges, x)
+		if bin == len(edges) || edges[bin] != x {
+			bin--
+		}
+		if bin == bins {
+			bin--
+		}
+		h.Counts[bin] += w
+		sumw2[bin] += w * w
+		h.Entries++
+		h.SumWeights += w
+	}
+
+	h.Centers = make([]float64, bins)
+	h.Errors = make([]float64, bins)
+	h.ErrorLow = make([]float64, bins)
+	h.ErrorHigh = make([]float64, bins)
+	for i := range h.Counts {
+		h.Centers[i] = (edges[i] + edges[i+1]) / 2
+		h.Errors[i] = math.Sqrt(sumw2[i])
+		if h.Weighted {
+			h.ErrorLow[i], h.ErrorHigh[i] = h.Errors[i], h.Errors[i]
+			continue
+		}
+		lower, upper := PoissonInterval(h.Counts[i])
+		h.ErrorLow[i] = h.Counts[i] - lower
+		h.ErrorHigh[i] = upper - h.Counts[i]
+	}
+
+	return h, nil
+}
+
+// binEdges computes the bin edges for the chosen rule
+func binEdges(xs []float64, opts Options) ([]float64, string, error) {
+	if len(opts.Edges) > 0 {
+		if len(opts.Edges) < 2 {
+			return nil, "", fmt.Errorf("at least two bin edges are required")
+		}
+		if len(opts.Edges) > MaxBins+1 {
+			return nil, "", fmt.Errorf("at most %d bins are allowed", MaxBins)
+		}
+		for i, edge := range opts.Edges {
+			if math.IsNaN(edge) || math.IsInf(edge, 0) {
+				return nil, "", fmt.Errorf("bin edges must be finite")
+			}
+			if i > 0 && edge <= opts.Edges[i-1] {
+				return nil, "", fmt.Errorf("bin edges must be strictly increasing")
+			}
+		}
+		return append([]float64(nil), opts.Edges...), BinningEdges, nil
+	}
+
+	lo, hi, err := binRange(xs, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var bins int
+	var rule string
+	switch {
+	case opts.Width < 0 || opts.Bins < 0:
+		return nil, "", fmt.Errorf("bin width and count must be positive")
+	case opts.Width > 0:
+		count := math.Ceil((hi - lo) / opts.Width)
+		if count > MaxBins {
+			return nil, "", fmt.Errorf("a width of %g gives more than %d bins", opts.Width, MaxBins)
+		}
+		// The last bin is full width, so the range may extend past hi
+		bins, rule = int(math.Max(1, count)), BinningWidth
+		hi = lo + float64(bins)*opts.Width
+	case opts.Bins > 0:
+		if opts.Bins > MaxBins {
+			return nil, "", fmt.Errorf("at most %d bins are allowed", MaxBins)
+		}
+		bins, rule = opts.Bins, BinningCount
+	default:
+		bins, rule = freedmanDiaconisBins(xs, lo, hi), BinningFreedmanDiaconis
+	}
+
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = lo + (hi-lo)*float64(i)/float64(bins)
+	}
+	edges[bins] = hi
+	return edges, rule, nil
+}
+
+// binRange returns the histogram range from the options or the data,
+// widening a degenerate range so every bin has a positive width
+func binRange(xs []float64, opts Options) (float64, float64, error) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, x := range xs {
+		lo = math.Min(lo, x)
+		hi = math.Max(hi, x)
+	}
+	if opts.Min != nil {
+		lo = *opts.Min
+	}
+	if opts.Max != nil {
+		hi = *opts.Max
+	}
+
+	switch {
+	case math.IsInf(lo, 0) || math.IsInf(hi, 0):
+		return 0, 0, fmt.Errorf("no values to bin; set min and max to bin an empty series")
+	case opts.Min != nil && opts.Max != nil && lo >= hi:
+		return 0, 0, fmt.Errorf("min must be less than max")
+	case lo > hi:
+		return 0, 0, fmt.Errorf("the range excludes every value")
+	case lo == hi:
+		lo, hi = lo-0.5, hi+0.5
+	}
+	return lo, hi, nil
+}
+
+// freedmanDiaconisBins applies the Freedman–Diaconis rule, bin width
+// 2·IQR/n^(1/3), over the values inside [lo, hi]. It falls back to Sturges'
+// rule when the interquartile range is zero.
+func freedmanDiaconisBins(xs []float64, lo, hi float64) int {
+	inside := make([]float64, 0, len(xs))
+	for _, x := range xs {
+		if x >= lo && x <= hi {
+			inside = append(inside, x)
+		}
+	}
+	n := float64(len(inside))
+	if n < 2 {
+		return 1
+	}
+
+	sort.Float64s(inside)
+	iqr := Quantile(inside, 0.75) - Quantile(inside, 0.25)
+	if iqr <= 0 {
+		return int(math.Ceil(math.Log2(n))) + 1
+	}
+
+	width := 2 * iqr / math.Cbrt(n)
+	bins := math.Ceil((hi - lo) / width)
+	return int(math.Max(1, math.Min(bins, MaxBins)))
+}
+
+// Quantile returns the q-quantile of sorted values using linear
+// interpolation between closest ranks
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	pos := q * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
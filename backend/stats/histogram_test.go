@@ -0,0 +1,210 @@
+package stats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func equalSlices(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !closeTo(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHistogramBinning(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []float64
+		opts    Options
+		binning string
+		edges   []float64
+		counts  []float64
+		under   float64
+		over    float64
+	}{
+		{
+			name:    "explicit edges, values on inner edges go up",
+			values:  []float64{0, 1, 1.5, 2, 3},
+			opts:    Options{Edges: []float64{0, 1, 2, 3}},
+			binning: BinningEdges,
+			edges:   []float64{0, 1, 2, 3},
+			counts:  []float64{1, 2, 2},
+		},
+		{
+			name:    "uneven edges with under- and overflow",
+			values:  []float64{-1, 0.5, 5, 9.9, 10, 11},
+			opts:    Options{Edges: []float64{0, 1, 10}},
+			binning: BinningEdges,
+			edges:   []float64{0, 1, 10},
+			counts:  []float64{1, 3},
+			under:   1,
+			over:    1,
+		},
+		{
+			name:    "bin count over the data range",
+			values:  []float64{0, 1, 2, 3, 4},
+			opts:    Options{Bins: 4},
+			binning: BinningCount,
+			edges:   []float64{0, 1, 2, 3, 4},
+			counts:  []float64{1, 1, 1, 2},
+		},
+		{
+			name:    "bin count over a fixed range",
+			values:  []float64{-5, 0.1, 0.6, 5},
+			opts:    Options{Bins: 2, Min: ptr(0), Max: ptr(1)},
+			binning: BinningCount,
+			edges:   []float64{0, 0.5, 1},
+			counts:  []float64{1, 1},
+			under:   1,
+			over:    1,
+		},
+		{
+			name:    "width extends the last bin past the maximum",
+			values:  []float64{0, 0.9, 2.5},
+			opts:    Options{Width: 1},
+			binning: BinningWidth,
+			edges:   []float64{0, 1, 2, 3},
+			counts:  []float64{2, 0, 1},
+		},
+		{
+			name:    "width from a set minimum",
+			values:  []float64{1, 2, 3},
+			opts:    Options{Width: 2, Min: ptr(-1)},
+			binning: BinningWidth,
+			edges:   []float64{-1, 1, 3},
+			counts:  []float64{0, 3},
+		},
+		{
+			name:    "single value widens to a unit range",
+			values:  []float64{2, 2, 2},
+			opts:    Options{Bins: 1},
+			binning: BinningCount,
+			edges:   []float64{1.5, 2.5},
+			counts:  []float64{3},
+		},
+		{
+			name:    "Freedman-Diaconis falls back to Sturges for zero IQR",
+			values:  []float64{1, 1, 1, 1, 1, 1, 1, 2},
+			binning: BinningFreedmanDiaconis,
+			edges:   []float64{1, 1.25, 1.5, 1.75, 2},
+			counts:  []float64{7, 0, 0, 1},
+		},
+		{
+			name:    "Freedman-Diaconis width 2·IQR/n^(1/3)",
+			values:  []float64{0, 1, 2, 3, 4, 5, 6, 7},
+			binning: BinningFreedmanDiaconis,
+			edges:   []float64{0, 3.5, 7},
+			counts:  []float64{4, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHistogram(tt.values, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("NewHistogram: %v", err)
+			}
+			if h.Binning != tt.binning {
+				t.Errorf("binning = %s, want %s", h.Binning, tt.binning)
+			}
+			if !equalSlices(h.Edges, tt.edges) {
+				t.Errorf("edges = %v, want %v", h.Edges, tt.edges)
+			}
+			if !equalSlices(h.Counts, tt.counts) {
+				t.Errorf("counts = %v, want %v", h.Counts, tt.counts)
+			}
+			if h.Underflow != tt.under || h.Overflow != tt.over {
+				t.Errorf("underflow, overflow = %g, %g, want %g, %g", h.Underflow, h.Overflow, tt.under, tt.over)
+			}
+			for i, center := range h.Centers {
+				if !closeTo(center, (h.Edges[i]+h.Edges[i+1])/2) {
+					t.Errorf("center %d = %g is not the bin midpoint", i, center)
+				}
+			}
+		})
+	}
+}
+
+func TestHistogramRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		opts   Options
+		want   string
+	}{
+		{"one edge", []float64{1}, Options{Edges: []float64{0}}, "at least two"},
+		{"decreasing edges", []float64{1}, Options{Edges: []float64{0, 2, 1}}, "strictly increasing"},
+		{"repeated edge", []float64{1}, Options{Edges: []float64{0, 1, 1}}, "strictly increasing"},
+		{"infinite edge", []float64{1}, Options{Edges: []float64{0, math.Inf(1)}}, "finite"},
+		{"too many bins", []float64{0, 1}, Options{Bins: MaxBins + 1}, "at most"},
+		{"width too small", []float64{0, 1}, Options{Width: 1e-9}, "more than"},
+		{"negative width", []float64{0, 1}, Options{Width: -1}, "positive"},
+		{"empty series", nil, Options{Bins: 2}, "no values"},
+		{"min above max", []float64{1}, Options{Min: ptr(2), Max: ptr(1)}, "less than max"},
+		{"range excludes the data", []float64{1, 2}, Options{Min: ptr(5)}, "excludes every value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHistogram(tt.values, nil, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogramWeightsAndErrors(t *testing.T) {
+	values := []float64{0.5, 0.5, 1.5, math.NaN(), 1.5}
+	weights := []float64{2, 3, 1, 1, math.Inf(1)}
+
+	h, err := NewHistogram(values, weights, Options{Edges: []float64{0, 1, 2}})
+	if err != nil {
+		t.Fatalf("NewHistogram: %v", err)
+	}
+	if h.Skipped != 2 || h.Entries != 3 || h.SumWeights != 6 {
+		t.Errorf("skipped, entries, sum = %d, %d, %g, want 2, 3, 6", h.Skipped, h.Entries, h.SumWeights)
+	}
+	if !equalSlices(h.Counts, []float64{5, 1}) {
+		t.Errorf("counts = %v, want [5 1]", h.Counts)
+	}
+	if want := []float64{math.Sqrt(13), 1}; !equalSlices(h.Errors, want) || !equalSlices(h.ErrorLow, want) || !equalSlices(h.ErrorHigh, want) {
+		t.Errorf("errors = %v, %v, %v, want sqrt(Σw²) = %v", h.Errors, h.ErrorLow, h.ErrorHigh, want)
+	}
+
+	if _, err := NewHistogram(values, weights[:2], Options{Bins: 1}); err == nil {
+		t.Error("expected an error for mismatched weights")
+	}
+}
+
+func TestPoissonInterval(t *testing.T) {
+	// Garwood 68.27% intervals, as tabulated by the PDG
+	tests := []struct {
+		n, lower, upper float64
+	}{
+		{0, 0, 1.841},
+		{1, 0.173, 3.300},
+		{2, 0.708, 4.638},
+		{10, 6.891, 14.267},
+	}
+
+	for _, tt := range tests {
+		lower, upper := PoissonInterval(tt.n)
+		if math.Abs(lower-tt.lower) > 1e-3 || math.Abs(upper-tt.upper) > 1e-3 {
+			t.Errorf("PoissonInterval(%g) = [%.4f, %.4f], want [%.3f, %.3f]", tt.n, lower, upper, tt.lower, tt.upper)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package stats holds the numerical routines behind the analysis endpoints:
+// extracting numeric series from dataset payloads, histogramming and the
+// special functions they rely on.
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Series extracts the numbers found at a dotted path such as
+// "measurements.invariant_mass". Arrays met along the path, including a
+// final array of numbers, are expanded, so the result holds one entry per
+// leaf. Leaves that are missing or not numbers are NaN, which keeps series
+// read from sibling paths (values and weights) aligned element by element.
+func Series(payload interface{}, path string) ([]float64, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+
+	var values []float64
+	found := false
+	var walk func(value interface{}, rest []string)
+	walk = func(value interface{}, rest []string) {
+		if items, ok := value.([]interface{}); ok {
+			for _, item := range items {
+				walk(item, rest)
+			}
+			return
+		}
+
+		if len(rest) == 0 {
+			found = true
+			if number, ok := value.(float64); ok {
+				values = append(values, number)
+			} else {
+				values = append(values, math.NaN())
+			}
+			return
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			values = append(values, math.NaN())
+			return
+		}
+		child, ok := object[rest[0]]
+		if !ok {
+			values = append(values, math.NaN())
+			return
+		}
+		walk(child, rest[1:])
+	}
+	walk(payload, segments)
+
+	if !found {
+		return nil, fmt.Errorf("path %q not found in the dataset", path)
+	}
+	return values, nil
+}
+
+// Finite returns the values that are neither NaN nor infinite
+func Finite(values []float64) []float64 {
+	out := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package stats
+
+import "math"
+
+// GammaP is the regularized lower incomplete gamma function P(a, x)
+func GammaP(a, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case math.IsInf(x, 1):
+		return 1
+	case x < a+1:
+		return gammaSeries(a, x)
+	default:
+		return 1 - gammaContinuedFraction(a, x)
+	}
+}
+
+// gammaSeries evaluates P(a, x) by its power series, which converges
+// quickly for x < a+1
+func gammaSeries(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+	sum := 1 / a
+	term := sum
+	for n := 1; n < 1000; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*1e-15 {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lgamma)
+}
+
+// gammaContinuedFraction evaluates Q(a, x) = 1 - P(a, x) by Lentz's method,
+// which converges quickly for x >= a+1
+func gammaContinuedFraction(a, x float64) float64 {
+	const tiny = 1e-300
+	lgamma, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lgamma) * h
+}
+
+// GammaQuantile returns x such that GammaP(a, x) = p for a gamma
+// distribution with shape a and unit scale
+func GammaQuantile(p, a float64) float64 {
+	if p <= 0 {
+		return 0
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+
+	// Bracket the root, then bisect; P is monotonic in x
+	lo, hi := 0.0, math.Max(1, a)
+	for GammaP(a, hi) < p {
+		lo = hi
+		hi *= 2
+	}
+	for i := 0; i < 200 && hi-lo > 1e-12*math.Max(1, hi); i++ {
+		mid := (lo + hi) / 2
+		if GammaP(a, mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// oneSigma is the probability content of a ±1σ Gaussian interval
+const oneSigma = 0.682689492137086
+
+// PoissonInterval returns the central 68.27% confidence interval for the
+// mean of a Poisson distribution after observing n events (Garwood)
+func PoissonInterval(n float64) (lower, upper float64) {
+	alpha := 1 - oneSigma
+	if n > 0 {
+		lower = GammaQuantile(alpha/2, n)
+	}
+	upper = GammaQuantile(1-alpha/2, n+1)
+	return lower, upper
+}
+
+// NormalSurvival returns P(Z > z) for a standard normal variable Z, the
+// one-sided p-value of a z-standard-deviation excess
+func NormalSurvival(z float64) float64 {
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
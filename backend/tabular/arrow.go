@@ -0,0 +1,331 @@
+package tabular
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+)
+
+// Arrow IPC enum values (format/Schema.fbs and format/Message.fbs)
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+
+	arrowPrecisionDouble = 2
+)
+
+// WriteArrow writes the table in the Arrow IPC streaming format: a schema
+// message, one record batch and the end-of-stream marker. Every field is
+// nullable; Int64, Float64, Bool and Utf8 columns are supported.
+func WriteArrow(w io.Writer, t *Table) error {
+	fields := make([]*fbTable, len(t.Columns))
+	for i, c := range t.Columns {
+		field := &fbTable{}
+		field.child(0, fbString(c.Name))
+		field.scalar(1, []byte{1}) // nullable
+		typeID, typeTable := arrowType(c.Type)
+		field.scalar(2, []byte{typeID})
+		field.child(3, typeTable)
+		field.child(5, fbTables{}) // children; readers reject a missing vector
+		fields[i] = field
+	}
+
+	schema := &fbTable{}
+	schema.child(1, fbTables(fields))
+	if err := writeArrowMessage(w, arrowHeaderSchema, schema, nil); err != nil {
+		return err
+	}
+
+	// Lay out the body: per column a validity bitmap, then offsets and data
+	// for strings or a single value buffer otherwise, each 8-byte aligned
+	var body []byte
+	var nodes, buffers []byte
+	addBuffer := func(data []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	for _, c := range t.Columns {
+		nulls := c.NullCount()
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(t.Rows))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(nulls))
+
+		if nulls > 0 {
+			validity := make([]byte, (t.Rows+7)/8)
+			for i, value := range c.Values {
+				if value != nil {
+					validity[i/8] |= 1 << (i % 8)
+				}
+			}
+			addBuffer(validity)
+		} else {
+			addBuffer(nil)
+		}
+
+		switch c.Type {
+		case ColumnInt64, ColumnFloat64:
+			values := make([]byte, 0, 8*t.Rows)
+			for _, value := range c.Values {
+				switch v := value.(type) {
+				case int64:
+					values = binary.LittleEndian.AppendUint64(values, uint64(v))
+				case float64:
+					values = binary.LittleEndian.AppendUint64(values, math.Float64bits(v))
+				default:
+					values = binary.LittleEndian.AppendUint64(values, 0)
+				}
+			}
+			addBuffer(values)
+		case ColumnBool:
+			values := make([]byte, (t.Rows+7)/8)
+			for i, value := range c.Values {
+				if v, ok := value.(bool); ok && v {
+					values[i/8] |= 1 << (i % 8)
+				}
+			}
+			addBuffer(values)
+		default:
+			offsets := make([]byte, 0, 4*(t.Rows+1))
+			var data []byte
+			offsets = binary.LittleEndian.AppendUint32(offsets, 0)
+			for _, value := range c.Values {
+				if v, ok := value.(string); ok {
+					data = append(data, v...)
+				}
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+			}
+			addBuffer(offsets)
+			addBuffer(data)
+		}
+	}
+
+	batch := &fbTable{}
+	batch.scalar(0, binary.LittleEndian.AppendUint64(nil, uint64(t.Rows)))
+	batch.child(1, fbStructs{size: 16, data: nodes})
+	batch.child(2, fbStructs{size: 16, data: buffers})
+	if err := writeArrowMessage(w, arrowHeaderRecordBatch, batch, body); err != nil {
+		return err
+	}
+
+	// End-of-stream marker: continuation token followed by a zero length
+	_, err := w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+func arrowType(t ColumnType) (byte, *fbTable) {
+	typeTable := &fbTable{}
+	switch t {
+	case ColumnInt64:
+		typeTable.scalar(0, binary.LittleEndian.AppendUint32(nil, 64))
+		typeTable.scalar(1, []byte{1}) // signed
+		return arrowTypeInt, typeTable
+	case ColumnFloat64:
+		typeTable.scalar(0, binary.LittleEndian.AppendUint16(nil, arrowPrecisionDouble))
+		return arrowTypeFloatingPoint, typeTable
+	case ColumnBool:
+		return arrowTypeBool, typeTable
+	default:
+		return arrowTypeUtf8, typeTable
+	}
+}
+
+// writeArrowMessage writes one encapsulated IPC message: the continuation
+// token, the padded metadata length, the Message flatbuffer and the body
+func writeArrowMessage(w io.Writer, headerType byte, header *fbTable, body []byte) error {
+	message := &fbTable{}
+	message.scalar(0, binary.LittleEndian.AppendUint16(nil, arrowMetadataV5))
+	message.scalar(1, []byte{headerType})
+	message.child(2, header)
+	message.scalar(3, binary.LittleEndian.AppendUint64(nil, uint64(len(body))))
+
+	metadata := (&fbBuilder{}).finish(message)
+	for (8+len(metadata))%8 != 0 {
+		metadata = append(metadata, 0)
+	}
+
+	prefix := []byte{0xff, 0xff, 0xff, 0xff}
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(metadata)))
+	for _, chunk := range [][]byte{prefix, metadata, body} {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The types below are a minimal FlatBuffers encoder. Unlike the official
+// builders it writes front to back: each table is preceded by its vtable
+// and followed by the strings, vectors and tables it references, so every
+// reference is a forward offset as the format requires.
+
+// fbTable is a table under construction; slots are indexed by field id
+type fbTable struct {
+	slots []fbSlot
+}
+
+type fbSlot struct {
+	present bool
+	scalar  []byte      // inline little-endian value
+	child   interface{} // fbString, *fbTable, fbTables or fbStructs
+}
+
+func (t *fbTable) slot(id int) *fbSlot {
+	for len(t.slots) <= id {
+		t.slots = append(t.slots, fbSlot{})
+	}
+	return &t.slots[id]
+}
+
+func (t *fbTable) scalar(id int, value []byte) {
+	*t.slot(id) = fbSlot{present: true, scalar: value}
+}
+
+func (t *fbTable) child(id int, value interface{}) {
+	*t.slot(id) = fbSlot{present: true, child: value}
+}
+
+type fbString string
+
+// fbTables is a vector of tables
+type fbTables []*fbTable
+
+// fbStructs is a vector of fixed-size structs with 8-byte alignment
+type fbStructs struct {
+	size int
+	data []byte
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) finish(root *fbTable) []byte {
+	b.buf = make([]byte, 4)
+	pos := b.table(root)
+	binary.LittleEndian.PutUint32(b.buf[0:], uint32(pos))
+	return b.buf
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// patch stores a forward offset from at to target
+func (b *fbBuilder) patch(at, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+func (b *fbBuilder) write(node interface{}) int {
+	switch n := node.(type) {
+	case fbString:
+		b.pad(4)
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(n)))
+		b.buf = append(b.buf, n...)
+		b.buf = append(b.buf, 0)
+		return pos
+	case *fbTable:
+		return b.table(n)
+	case fbTables:
+		b.pad(4)
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(n)))
+		b.buf = append(b.buf, make([]byte, 4*len(n))...)
+		for i, table := range n {
+			b.patch(pos+4+4*i, b.table(table))
+		}
+		return pos
+	case fbStructs:
+		// The element data after the length prefix must be 8-byte aligned
+		for (len(b.buf)+4)%8 != 0 {
+			b.buf = append(b.buf, 0)
+		}
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(n.data)/n.size))
+		b.buf = append(b.buf, n.data...)
+		return pos
+	}
+	panic("flatbuffers: unsupported node")
+}
+
+func (b *fbBuilder) table(t *fbTable) int {
+	// Lay out inline fields after the 4-byte vtable offset, largest first
+	type placed struct {
+		id, offset, size int
+	}
+	var fields []placed
+	for id, slot := range t.slots {
+		if !slot.present {
+			continue
+		}
+		size := 4
+		if slot.child == nil {
+			size = len(slot.scalar)
+		}
+		fields = append(fields, placed{id: id, size: size})
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].size > fields[j].size })
+
+	tableSize, align := 4, 4
+	for i := range fields {
+		for tableSize%fields[i].size != 0 {
+			tableSize++
+		}
+		fields[i].offset = tableSize
+		tableSize += fields[i].size
+		if fields[i].size > align {
+			align = fields[i].size
+		}
+	}
+
+	// vtable: its size, the table size and one offset per slot
+	b.pad(2)
+	vtablePos := len(b.buf)
+	vtable := binary.LittleEndian.AppendUint16(nil, uint16(4+2*len(t.slots)))
+	vtable = binary.LittleEndian.AppendUint16(vtable, uint16(tableSize))
+	offsets := make([]uint16, len(t.slots))
+	for _, f := range fields {
+		offsets[f.id] = uint16(f.offset)
+	}
+	for _, offset := range offsets {
+		vtable = binary.LittleEndian.AppendUint16(vtable, offset)
+	}
+	b.buf = append(b.buf, vtable...)
+
+	b.pad(align)
+	tablePos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, tableSize)...)
+	binary.LittleEndian.PutUint32(b.buf[tablePos:], uint32(int32(tablePos-vtablePos)))
+
+	for _, f := range fields {
+		slot := t.slots[f.id]
+		if slot.child == nil {
+			copy(b.buf[tablePos+f.offset:], slot.scalar)
+		}
+	}
+
+	// Referenced objects follow the table, in slot order
+	for _, f := range fields {
+		slot := t.slots[f.id]
+		if slot.child != nil {
+			at := tablePos + f.offset
+			b.patch(at, b.write(slot.child))
+		}
+	}
+
+	return tablePos
+}
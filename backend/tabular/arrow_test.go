@@ -0,0 +1,254 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWriteArrowRoundTrip(t *testing.T) {
+	for name, table := range testTables() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteArrow(&buf, table); err != nil {
+				t.Fatalf("WriteArrow: %v", err)
+			}
+			stream := buf.Bytes()
+
+			schema, _, rest := readArrowMessage(t, stream, arrowHeaderSchema)
+			fields := schema.tables(1)
+			if len(fields) != len(table.Columns) {
+				t.Fatalf("schema has %d fields, want %d", len(fields), len(table.Columns))
+			}
+			for i, c := range table.Columns {
+				checkArrowField(t, fields[i], c)
+			}
+
+			batch, body, rest := readArrowMessage(t, rest, arrowHeaderRecordBatch)
+			if got := batch.int64(0); got != int64(table.Rows) {
+				t.Errorf("batch length = %d, want %d", got, table.Rows)
+			}
+			nodes, buffers := batch.structs(1, 16), batch.structs(2, 16)
+			if len(nodes) != len(table.Columns) {
+				t.Fatalf("batch has %d field nodes, want %d", len(nodes), len(table.Columns))
+			}
+
+			for i, c := range table.Columns {
+				node := nodes[i]
+				if length := binary.LittleEndian.Uint64(node); length != uint64(table.Rows) {
+					t.Errorf("column %s: node length = %d", c.Name, length)
+				}
+				if nulls := binary.LittleEndian.Uint64(node[8:]); nulls != uint64(c.NullCount()) {
+					t.Errorf("column %s: null count = %d, want %d", c.Name, nulls, c.NullCount())
+				}
+
+				var columnBuffers [][]byte
+				count := 2
+				if c.Type == ColumnString {
+					count = 3
+				}
+				for _, b := range buffers[:count] {
+					offset, length := binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])
+					if offset%8 != 0 {
+						t.Errorf("column %s: buffer at body offset %d is not 8-byte aligned", c.Name, offset)
+					}
+					columnBuffers = append(columnBuffers, body[offset:offset+length])
+				}
+				buffers = buffers[count:]
+
+				values := decodeArrowColumn(c.Type, table.Rows, columnBuffers)
+				if !reflect.DeepEqual(values, c.Values) {
+					t.Errorf("column %s: read %v, want %v", c.Name, values, c.Values)
+				}
+			}
+			if len(buffers) != 0 {
+				t.Errorf("%d buffers left over", len(buffers))
+			}
+
+			if !bytes.Equal(rest, []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}) {
+				t.Errorf("stream ends with %x, want the end-of-stream marker", rest)
+			}
+		})
+	}
+}
+
+// checkArrowField compares a schema Field table with the column
+func checkArrowField(t *testing.T, field fbRef, c *Column) {
+	t.Helper()
+
+	if name := field.string(0); name != c.Name {
+		t.Errorf("field name = %q, want %q", name, c.Name)
+	}
+	if field.uint8(1) != 1 {
+		t.Errorf("field %s is not nullable", c.Name)
+	}
+	if !field.has(5) || len(field.tables(5)) != 0 {
+		t.Errorf("field %s: want an empty children vector", c.Name)
+	}
+
+	typ := field.table(3)
+	want := map[ColumnType]byte{
+		ColumnInt64:   arrowTypeInt,
+		ColumnFloat64: arrowTypeFloatingPoint,
+		ColumnBool:    arrowTypeBool,
+		ColumnString:  arrowTypeUtf8,
+	}[c.Type]
+	if got := field.uint8(2); got != want {
+		t.Errorf("field %s: type %d, want %d", c.Name, got, want)
+	}
+	switch c.Type {
+	case ColumnInt64:
+		if typ.uint32(0) != 64 || typ.uint8(1) != 1 {
+			t.Errorf("field %s: want a signed 64-bit Int", c.Name)
+		}
+	case ColumnFloat64:
+		if typ.uint16(0) != arrowPrecisionDouble {
+			t.Errorf("field %s: want DOUBLE precision", c.Name)
+		}
+	}
+}
+
+// readArrowMessage reads one encapsulated IPC message of the given header
+// type and returns its header table, its body and the rest of the stream
+func readArrowMessage(t *testing.T, stream []byte, headerType byte) (fbRef, []byte, []byte) {
+	t.Helper()
+
+	if !bytes.HasPrefix(stream, []byte{0xff, 0xff, 0xff, 0xff}) {
+		t.Fatalf("message does not start with the continuation token: %x", stream[:min(8, len(stream))])
+	}
+	length := int(binary.LittleEndian.Uint32(stream[4:]))
+	if (8+length)%8 != 0 {
+		t.Errorf("metadata of %d bytes leaves the body unaligned", length)
+	}
+	metadata := stream[8 : 8+length]
+	message := fbRef{buf: metadata, pos: int(binary.LittleEndian.Uint32(metadata))}
+
+	if version := message.uint16(0); version != arrowMetadataV5 {
+		t.Errorf("metadata version = %d, want %d", version, arrowMetadataV5)
+	}
+	if got := message.uint8(1); got != headerType {
+		t.Fatalf("header type = %d, want %d", got, headerType)
+	}
+	bodyLength := int(message.int64(3))
+	body := stream[8+length : 8+length+bodyLength]
+	return message.table(2), body, stream[8+length+bodyLength:]
+}
+
+// decodeArrowColumn reads a column from its validity bitmap and value
+// buffers; an empty validity bitmap means no nulls
+func decodeArrowColumn(typ ColumnType, rows int, buffers [][]byte) []interface{} {
+	var out []interface{}
+	for i := 0; i < rows; i++ {
+		if validity := buffers[0]; len(validity) > 0 && validity[i/8]&(1<<(i%8)) == 0 {
+			out = append(out, nil)
+			continue
+		}
+		switch typ {
+		case ColumnInt64:
+			out = append(out, int64(binary.LittleEndian.Uint64(buffers[1][8*i:])))
+		case ColumnFloat64:
+			out = append(out, math.Float64frombits(binary.LittleEndian.Uint64(buffers[1][8*i:])))
+		case ColumnBool:
+			out = append(out, buffers[1][i/8]&(1<<(i%8)) != 0)
+		default:
+			start := binary.LittleEndian.Uint32(buffers[1][4*i:])
+			end := binary.LittleEndian.Uint32(buffers[1][4*i+4:])
+			out = append(out, string(buffers[2][start:end]))
+		}
+	}
+	return out
+}
+
+// fbRef is a FlatBuffers table read by field id, independently of
+// fbBuilder so the tests check the bytes rather than the writer
+type fbRef struct {
+	buf []byte
+	pos int
+}
+
+// field returns the position of a field's inline value, or 0 if absent
+func (r fbRef) field(id int) int {
+	vtable := r.pos - int(int32(binary.LittleEndian.Uint32(r.buf[r.pos:])))
+	vtableSize := int(binary.LittleEndian.Uint16(r.buf[vtable:]))
+	if 4+2*id >= vtableSize {
+		return 0
+	}
+	offset := int(binary.LittleEndian.Uint16(r.buf[vtable+4+2*id:]))
+	if offset == 0 {
+		return 0
+	}
+	return r.pos + offset
+}
+
+func (r fbRef) has(id int) bool {
+	return r.field(id) != 0
+}
+
+func (r fbRef) uint8(id int) byte {
+	if at := r.field(id); at != 0 {
+		return r.buf[at]
+	}
+	return 0
+}
+
+func (r fbRef) uint16(id int) uint16 {
+	if at := r.field(id); at != 0 {
+		return binary.LittleEndian.Uint16(r.buf[at:])
+	}
+	return 0
+}
+
+func (r fbRef) uint32(id int) uint32 {
+	if at := r.field(id); at != 0 {
+		return binary.LittleEndian.Uint32(r.buf[at:])
+	}
+	return 0
+}
+
+func (r fbRef) int64(id int) int64 {
+	if at := r.field(id); at != 0 {
+		return int64(binary.LittleEndian.Uint64(r.buf[at:]))
+	}
+	return 0
+}
+
+// deref follows the forward offset stored in a field
+func (r fbRef) deref(id int) int {
+	at := r.field(id)
+	return at + int(binary.LittleEndian.Uint32(r.buf[at:]))
+}
+
+func (r fbRef) table(id int) fbRef {
+	return fbRef{buf: r.buf, pos: r.deref(id)}
+}
+
+func (r fbRef) string(id int) string {
+	at := r.deref(id)
+	n := int(binary.LittleEndian.Uint32(r.buf[at:]))
+	return string(r.buf[at+4 : at+4+n])
+}
+
+func (r fbRef) tables(id int) []fbRef {
+	at := r.deref(id)
+	n := int(binary.LittleEndian.Uint32(r.buf[at:]))
+	tables := make([]fbRef, n)
+	for i := range tables {
+		elem := at + 4 + 4*i
+		tables[i] = fbRef{buf: r.buf, pos: elem + int(binary.LittleEndian.Uint32(r.buf[elem:]))}
+	}
+	return tables
+}
+
+// structs returns the elements of a vector of fixed-size structs
+func (r fbRef) structs(id, size int) [][]byte {
+	at := r.deref(id)
+	n := int(binary.LittleEndian.Uint32(r.buf[at:]))
+	elems := make([][]byte, n)
+	for i := range elems {
+		start := at + 4 + size*i
+		elems[i] = r.buf[start : start+size]
+	}
+	return elems
+}
@@ -0,0 +1,110 @@
+package tabular
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Format is a tabular export format
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	Write       func(w io.Writer, t *Table) error
+}
+
+// Formats lists the supported export formats; the first is the default
+var Formats = []Format{
+	{Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: ".csv", Write: WriteCSV},
+	{Name: "jsonl", ContentType: "application/x-ndjson", Extension: ".jsonl", Write: WriteJSONL},
+	{Name: "parquet", ContentType: "application/vnd.apache.parquet", Extension: ".parquet", Write: WriteParquet},
+	{Name: "arrow", ContentType: "application/vnd.apache.arrow.stream", Extension: ".arrows", Write: WriteArrow},
+}
+
+// FormatByName returns the named export format
+func FormatByName(name string) (Format, bool) {
+	for _, format := range Formats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// WriteCSV writes the table as CSV with a header row; missing values are empty
+func WriteCSV(w io.Writer, t *Table) error {
+	writer := csv.NewWriter(w)
+
+	record := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		record[i] = c.Name
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for row := 0; row < t.Rows; row++ {
+		for i, c := range t.Columns {
+			record[i] = formatCell(c.Values[row])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSONL writes one JSON object per row, keeping the column order
+func WriteJSONL(w io.Writer, t *Table) error {
+	buffered := bufio.NewWriter(w)
+
+	names := make([][]byte, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i], _ = json.Marshal(c.Name)
+	}
+
+	for row := 0; row < t.Rows; row++ {
+		buffered.WriteByte('{')
+		for i, c := range t.Columns {
+			if i > 0 {
+				buffered.WriteByte(',')
+			}
+			buffered.Write(names[i])
+			buffered.WriteByte(':')
+
+			value, err := json.Marshal(c.Values[row])
+			if err != nil {
+				return err
+			}
+			buffered.Write(value)
+		}
+		if _, err := buffered.WriteString("}\n"); err != nil {
+			return err
+		}
+	}
+
+	return buffered.Flush()
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
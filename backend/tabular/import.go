@@ -0,0 +1,254 @@
+// Package tabular converts between delimited text files and the record arrays
+// stored in dataset payloads (measurements, collisions, metrics, ...).
+package tabular
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Column value types understood by a Mapping
+const (
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeString  = "string"
+	TypeBoolean = "boolean"
+)
+
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ColumnMapping says which file column fills a record field and how to parse it.
+// In JSON it is either an object or just the column name, e.g. "m_yy".
+type ColumnMapping struct {
+	Column   string `json:"column"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional"`
+}
+
+// UnmarshalJSON accepts either a bare column name or a full mapping object
+func (c *ColumnMapping) UnmarshalJSON(data []byte) error {
+	var column string
+	if err := json.Unmarshal(data, &column); err == nil {
+		*c = ColumnMapping{Column: column}
+		return nil
+	}
+
+	type plain ColumnMapping
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// Mapping describes how the rows of a file become payload records
+type Mapping struct {
+	// Target is the payload key the records are stored under, e.g. "measurements"
+	Target string `json:"target"`
+	// Columns maps record field names to file columns
+	Columns map[string]ColumnMapping `json:"columns"`
+	// Metadata holds extra top-level payload fields such as units or experiment
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// Validate checks the mapping and fills in default column types
+func (m *Mapping) Validate() error {
+	var problems []string
+
+	if !fieldNamePattern.MatchString(m.Target) {
+		problems = append(problems, "target must be a field name such as \"measurements\"")
+	}
+	if _, clash := m.Metadata[m.Target]; clash {
+		problems = append(problems, fmt.Sprintf("metadata must not contain the target field %q", m.Target))
+	}
+	if len(m.Columns) == 0 {
+		problems = append(problems, "columns must map at least one field")
+	}
+
+	for field, column := range m.Columns {
+		if !fieldNamePattern.MatchString(field) {
+			problems = append(problems, fmt.Sprintf("columns: %q is not a valid field name", field))
+		}
+		if column.Column == "" {
+			problems = append(problems, fmt.Sprintf("columns.%s: column is required", field))
+		}
+		switch column.Type {
+		case "":
+			column.Type = TypeNumber
+			m.Columns[field] = column
+		case TypeNumber, TypeInteger, TypeString, TypeBoolean:
+		default:
+			problems = append(problems, fmt.Sprintf("columns.%s: unknown type %q", field, column.Type))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// RowError reports a row that could not be converted
+type RowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult is the outcome of reading a file
+type ImportResult struct {
+	Records    []map[string]interface{}
+	RowsRead   int
+	Errors     []RowError // first MaxErrors problems
+	ErrorCount int        // rows with at least one problem
+}
+
+// Options tune a Read call
+type Options struct {
+	Comma     rune // field delimiter, ',' or '\t'
+	MaxRows   int  // reading fails once more data rows than this are seen; 0 means no limit
+	MaxErrors int  // how many row errors to keep in the result
+}
+
+// Read converts a delimited file with a header row into records, one row at
+// a time, so the file itself is never held in memory. Rows that fail to
+// convert are left out of Records and reported in Errors.
+func Read(r io.Reader, mapping *Mapping, opts Options) (*ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Comma
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		// Spreadsheet exports often start with a UTF-8 byte order mark
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		index[name] = i
+	}
+
+	type boundColumn struct {
+		field string
+		index int
+		ColumnMapping
+	}
+	var columns []boundColumn
+	var missing []string
+	for field, column := range mapping.Columns {
+		i, ok := index[column.Column]
+		if !ok {
+			missing = append(missing, column.Column)
+			continue
+		}
+		columns = append(columns, boundColumn{field: field, index: i, ColumnMapping: column})
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("columns not found in header: %s", strings.Join(missing, ", "))
+	}
+
+	result := &ImportResult{}
+	addError := func(rowErr RowError) {
+		if len(result.Errors) < opts.MaxErrors {
+			result.Errors = append(result.Errors, rowErr)
+		}
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		result.RowsRead++
+		if opts.MaxRows > 0 && result.RowsRead > opts.MaxRows {
+			return nil, fmt.Errorf("file has more than %d data rows", opts.MaxRows)
+		}
+
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			// Malformed quoting or a failed read makes the rest of the file unreliable
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			result.ErrorCount++
+			addError(RowError{Line: line, Message: "wrong number of fields"})
+			continue
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		failed := false
+		for _, column := range columns {
+			value, err := convert(strings.TrimSpace(row[column.index]), column.ColumnMapping)
+			if err != nil {
+				failed = true
+				addError(RowError{Line: line, Column: column.Column, Message: err.Error()})
+				continue
+			}
+			if value != nil {
+				record[column.field] = value
+			}
+		}
+
+		if failed {
+			result.ErrorCount++
+			continue
+		}
+		result.Records = append(result.Records, record)
+	}
+
+	return result, nil
+}
+
+// Payload assembles the dataset payload from the mapping metadata and records
+func (m *Mapping) Payload(records []map[string]interface{}) map[string]interface{} {
+	payload := make(map[string]interface{}, len(m.Metadata)+1)
+	for key, value := range m.Metadata {
+		payload[key] = value
+	}
+	payload[m.Target] = records
+	return payload
+}
+
+// convert parses one cell; it returns nil for an empty optional cell
+func convert(cell string, column ColumnMapping) (interface{}, error) {
+	if cell == "" {
+		if column.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("value is required")
+	}
+
+	switch column.Type {
+	case TypeString:
+		return cell, nil
+	case TypeInteger:
+		v, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", cell)
+		}
+		return v, nil
+	case TypeBoolean:
+		v, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", cell)
+		}
+		return v, nil
+	default:
+		v, err := strconv.ParseFloat(cell, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%q is not a finite number", cell)
+		}
+		return v, nil
+	}
+}
@@ -0,0 +1,307 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Parquet enum values used by the writer (parquet-format, parquet.thrift)
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1 // FieldRepetitionType
+	parquetUTF8     = 0 // ConvertedType
+
+	parquetPlain = 0 // Encoding
+	parquetRLE   = 3
+
+	parquetUncompressed = 0 // CompressionCodec
+	parquetDataPage     = 0 // PageType
+)
+
+// WriteParquet writes the table as an uncompressed Parquet file with one row
+// group and one PLAIN-encoded data page per column. Every column is OPTIONAL
+// so missing values round-trip as nulls. The table is already in memory and
+// each column's page is encoded into a buffer before it is written, so peak
+// memory is the table plus its largest column page.
+func WriteParquet(w io.Writer, t *Table) error {
+	out := &countingWriter{w: w}
+	if _, err := out.Write([]byte("PAR1")); err != nil {
+		return err
+	}
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(t.Columns))
+
+	for i, c := range t.Columns {
+		page, err := parquetPage(c, t.Rows)
+		if err != nil {
+			return err
+		}
+
+		header := &thriftWriter{}
+		header.structBegin()
+		header.i32Field(1, parquetDataPage)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structField(5)
+		header.i32Field(1, int32(t.Rows))
+		header.i32Field(2, parquetPlain)
+		header.i32Field(3, parquetRLE)
+		header.i32Field(4, parquetRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunks[i].offset = out.n
+		if _, err := out.Write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := out.Write(page); err != nil {
+			return err
+		}
+		chunks[i].size = out.n - chunks[i].offset
+	}
+
+	meta := &thriftWriter{}
+	meta.structBegin()
+	meta.i32Field(1, 1) // version
+
+	meta.listField(2, thriftStruct, len(t.Columns)+1)
+	meta.structBegin()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(t.Columns)))
+	meta.structEnd()
+	for _, c := range t.Columns {
+		meta.structBegin()
+		meta.i32Field(1, parquetPhysicalType(c.Type))
+		meta.i32Field(3, parquetOptional)
+		meta.stringField(4, c.Name)
+		if c.Type == ColumnString {
+			meta.i32Field(6, parquetUTF8)
+		}
+		meta.structEnd()
+	}
+
+	meta.i64Field(3, int64(t.Rows))
+
+	var totalSize int64
+	for _, ch := range chunks {
+		totalSize += ch.size
+	}
+
+	meta.listField(4, thriftStruct, 1)
+	meta.structBegin() // RowGroup
+	meta.listField(1, thriftStruct, len(t.Columns))
+	for i, c := range t.Columns {
+		meta.structBegin() // ColumnChunk
+		meta.i64Field(2, chunks[i].offset)
+		meta.structField(3) // ColumnMetaData
+		meta.i32Field(1, parquetPhysicalType(c.Type))
+		meta.listField(2, thriftI32, 2)
+		meta.i32Elem(parquetPlain)
+		meta.i32Elem(parquetRLE)
+		meta.listField(3, thriftBinary, 1)
+		meta.stringElem(c.Name)
+		meta.i32Field(4, parquetUncompressed)
+		meta.i64Field(5, int64(t.Rows))
+		meta.i64Field(6, chunks[i].size)
+		meta.i64Field(7, chunks[i].size)
+		meta.i64Field(9, chunks[i].offset)
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.i64Field(2, totalSize)
+	meta.i64Field(3, int64(t.Rows))
+	meta.structEnd()
+
+	meta.stringField(6, "fresherpaint")
+	meta.structEnd()
+
+	footer := meta.buf.Bytes()
+	if _, err := out.Write(footer); err != nil {
+		return err
+	}
+	if err := binary.Write(out, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return err
+	}
+	_, err := out.Write([]byte("PAR1"))
+	return err
+}
+
+func parquetPhysicalType(t ColumnType) int32 {
+	switch t {
+	case ColumnInt64:
+		return parquetInt64
+	case ColumnFloat64:
+		return parquetDouble
+	case ColumnBool:
+		return parquetBoolean
+	default:
+		return parquetByteArray
+	}
+}
+
+// parquetPage encodes the definition levels and PLAIN values of a column.
+// Levels use the bit-packed form of the RLE/bit-packing hybrid with width 1.
+func parquetPage(c *Column, rows int) ([]byte, error) {
+	groups := (rows + 7) / 8
+	levels := &bytes.Buffer{}
+	writeUvarint(levels, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, value := range c.Values {
+		if value != nil {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	levels.Write(packed)
+
+	page := &bytes.Buffer{}
+	binary.Write(page, binary.LittleEndian, uint32(levels.Len()))
+	page.Write(levels.Bytes())
+
+	switch c.Type {
+	case ColumnBool:
+		var bits []byte
+		n := 0
+		for _, value := range c.Values {
+			if value == nil {
+				continue
+			}
+			if n%8 == 0 {
+				bits = append(bits, 0)
+			}
+			if value.(bool) {
+				bits[n/8] |= 1 << (n % 8)
+			}
+			n++
+		}
+		page.Write(bits)
+	default:
+		var scratch [8]byte
+		for _, value := range c.Values {
+			switch v := value.(type) {
+			case nil:
+			case int64:
+				page.Write(binary.LittleEndian.AppendUint64(scratch[:0], uint64(v)))
+			case float64:
+				page.Write(binary.LittleEndian.AppendUint64(scratch[:0], math.Float64bits(v)))
+			case string:
+				page.Write(binary.LittleEndian.AppendUint32(scratch[:0], uint32(len(v))))
+				page.WriteString(v)
+			default:
+				return nil, fmt.Errorf("column %s: unexpected value %T", c.Name, value)
+			}
+		}
+	}
+
+	return page.Bytes(), nil
+}
+
+// Thrift compact protocol type codes
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes just enough of the Thrift compact protocol for
+// Parquet page headers and file metadata
+type thriftWriter struct {
+	buf    bytes.Buffer
+	last   int16
+	parent []int16
+}
+
+func (t *thriftWriter) structBegin() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.last = t.parent[len(t.parent)-1]
+	t.parent = t.parent[:len(t.parent)-1]
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		writeUvarint(&t.buf, zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) i32Field(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	writeUvarint(&t.buf, zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64Field(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	writeUvarint(&t.buf, zigzag(v))
+}
+
+func (t *thriftWriter) stringField(id int16, v string) {
+	t.fieldHeader(id, thriftBinary)
+	t.stringElem(v)
+}
+
+// structField starts a nested struct field; close it with structEnd
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBegin()
+}
+
+// listField starts a list field; write size elements after it
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		writeUvarint(&t.buf, uint64(size))
+	}
+}
+
+func (t *thriftWriter) i32Elem(v int32) {
+	writeUvarint(&t.buf, zigzag(int64(v)))
+}
+
+func (t *thriftWriter) stringElem(v string) {
+	writeUvarint(&t.buf, uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+}
+
+// countingWriter tracks the number of bytes written so far
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
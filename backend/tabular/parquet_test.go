@@ -0,0 +1,252 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testTables are written by the Parquet and Arrow tests and decoded again.
+// The sample spans more than one byte of validity bits and has nulls,
+// negative numbers, empty and multi-byte strings.
+func testTables() map[string]*Table {
+	return map[string]*Table{
+		"sample": {
+			Name: "measurements",
+			Rows: 10,
+			Columns: []*Column{
+				{Name: "id", Type: ColumnInt64, Values: []interface{}{int64(1), int64(-2), nil, int64(4), int64(5), int64(6), int64(7), int64(8), int64(math.MaxInt64), int64(math.MinInt64)}},
+				{Name: "value", Type: ColumnFloat64, Values: []interface{}{0.5, -1.25, 3.0, nil, nil, 1e300, 0.0, 2.5, 7.0, math.SmallestNonzeroFloat64}},
+				{Name: "valid", Type: ColumnBool, Values: []interface{}{true, false, true, true, nil, false, false, true, true, nil}},
+				{Name: "label", Type: ColumnString, Values: []interface{}{"a", "", nil, "Δx", "long label", "b", "c", nil, "d", "ü"}},
+			},
+		},
+		"no rows": {
+			Name: "empty",
+			Columns: []*Column{
+				{Name: "id", Type: ColumnInt64},
+				{Name: "label", Type: ColumnString},
+			},
+		},
+	}
+}
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	for name, table := range testTables() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteParquet(&buf, table); err != nil {
+				t.Fatalf("WriteParquet: %v", err)
+			}
+			file := buf.Bytes()
+
+			if !bytes.HasPrefix(file, []byte("PAR1")) || !bytes.HasSuffix(file, []byte("PAR1")) {
+				t.Fatal("file does not start and end with PAR1")
+			}
+			footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+			footerStart := len(file) - 8 - footerLen
+			meta := (&thriftReader{buf: file[footerStart : len(file)-8]}).readStruct()
+
+			if meta[1] != int64(1) {
+				t.Errorf("version = %v, want 1", meta[1])
+			}
+			if meta[3] != int64(table.Rows) {
+				t.Errorf("num_rows = %v, want %d", meta[3], table.Rows)
+			}
+
+			// Schema: a root with one OPTIONAL leaf per column
+			schema := meta[2].([]interface{})
+			if len(schema) != len(table.Columns)+1 {
+				t.Fatalf("schema has %d elements, want %d", len(schema), len(table.Columns)+1)
+			}
+			root := schema[0].(thriftFields)
+			if root[4] != "schema" || root[5] != int64(len(table.Columns)) {
+				t.Errorf("schema root = %v", root)
+			}
+			for i, c := range table.Columns {
+				element := schema[i+1].(thriftFields)
+				if element[4] != c.Name || element[1] != int64(parquetPhysicalType(c.Type)) || element[3] != int64(parquetOptional) {
+					t.Errorf("schema element %d = %v, want optional %s", i, element, c.Name)
+				}
+				if converted, ok := element[6]; (c.Type == ColumnString) != ok || (ok && converted != int64(parquetUTF8)) {
+					t.Errorf("column %s: converted type = %v", c.Name, converted)
+				}
+			}
+
+			rowGroups := meta[4].([]interface{})
+			if len(rowGroups) != 1 {
+				t.Fatalf("got %d row groups, want 1", len(rowGroups))
+			}
+			group := rowGroups[0].(thriftFields)
+			chunks := group[1].([]interface{})
+			if len(chunks) != len(table.Columns) || group[3] != int64(table.Rows) {
+				t.Fatalf("row group = %v", group)
+			}
+
+			// Each chunk is a page header and one page, packed from byte 4
+			// up to the footer
+			next := int64(4)
+			var totalSize int64
+			for i, c := range table.Columns {
+				chunk := chunks[i].(thriftFields)
+				cm := chunk[3].(thriftFields)
+				offset, size := cm[9].(int64), cm[7].(int64)
+				if chunk[2] != offset || offset != next || cm[6] != size {
+					t.Errorf("column %s: chunk at %v (%d bytes), want %d", c.Name, offset, size, next)
+				}
+				if cm[1] != int64(parquetPhysicalType(c.Type)) || cm[4] != int64(parquetUncompressed) || cm[5] != int64(table.Rows) {
+					t.Errorf("column %s: column metadata = %v", c.Name, cm)
+				}
+				if path := cm[3].([]interface{}); len(path) != 1 || path[0] != c.Name {
+					t.Errorf("column %s: path_in_schema = %v", c.Name, path)
+				}
+				next += size
+				totalSize += size
+
+				values := readParquetPage(t, file[offset:offset+size], c.Type, table.Rows)
+				if !reflect.DeepEqual(values, c.Values) {
+					t.Errorf("column %s: read %v, want %v", c.Name, values, c.Values)
+				}
+			}
+			if next != int64(footerStart) {
+				t.Errorf("column chunks end at %d, footer starts at %d", next, footerStart)
+			}
+			if group[2] != totalSize {
+				t.Errorf("total_byte_size = %v, want %d", group[2], totalSize)
+			}
+		})
+	}
+}
+
+// readParquetPage decodes a column chunk holding one uncompressed data page
+// with bit-packed definition levels and PLAIN values
+func readParquetPage(t *testing.T, chunk []byte, typ ColumnType, rows int) []interface{} {
+	t.Helper()
+
+	r := &thriftReader{buf: chunk}
+	header := r.readStruct()
+	page := chunk[r.pos:]
+	if header[1] != int64(parquetDataPage) || header[2] != int64(len(page)) || header[3] != int64(len(page)) {
+		t.Fatalf("page header = %v, page is %d bytes", header, len(page))
+	}
+	data := header[5].(thriftFields)
+	if data[1] != int64(rows) || data[2] != int64(parquetPlain) || data[3] != int64(parquetRLE) {
+		t.Fatalf("data page header = %v", data)
+	}
+
+	levelsLen := int(binary.LittleEndian.Uint32(page))
+	levels := &thriftReader{buf: page[4 : 4+levelsLen]}
+	run := levels.uvarint()
+	if run&1 != 1 || int(run>>1) != (rows+7)/8 {
+		t.Fatalf("definition levels header = %d, want a bit-packed run of %d groups", run, (rows+7)/8)
+	}
+	bits := levels.buf[levels.pos:]
+
+	values := &thriftReader{buf: page[4+levelsLen:]}
+	var out []interface{}
+	present := 0
+	for i := 0; i < rows; i++ {
+		if bits[i/8]&(1<<(i%8)) == 0 {
+			out = append(out, nil)
+			continue
+		}
+		switch typ {
+		case ColumnInt64:
+			out = append(out, int64(binary.LittleEndian.Uint64(values.next(8))))
+		case ColumnFloat64:
+			out = append(out, math.Float64frombits(binary.LittleEndian.Uint64(values.next(8))))
+		case ColumnBool:
+			out = append(out, values.buf[present/8]&(1<<(present%8)) != 0)
+		default:
+			n := int(binary.LittleEndian.Uint32(values.next(4)))
+			out = append(out, string(values.next(n)))
+		}
+		present++
+	}
+	if typ == ColumnBool {
+		values.pos = (present + 7) / 8
+	}
+	if values.pos != len(values.buf) {
+		t.Errorf("%d bytes left after the values", len(values.buf)-values.pos)
+	}
+	return out
+}
+
+// thriftFields is a decoded Thrift struct by field id. Integers decode as
+// int64, binary as string, lists as []interface{}.
+type thriftFields map[int16]interface{}
+
+// thriftReader decodes the Thrift compact protocol, independently of
+// thriftWriter so the tests check the bytes rather than the writer
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) next(n int) []byte {
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		panic("thrift: bad varint")
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	u := r.uvarint()
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (r *thriftReader) readStruct() thriftFields {
+	s := thriftFields{}
+	var last int16
+	for {
+		b := r.next(1)[0]
+		if b == 0 {
+			return s
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.varint())
+		}
+		s[id] = r.readValue(b & 0x0f)
+		last = id
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) interface{} {
+	switch typ {
+	case 1, 2: // boolean true and false, in field headers
+		return typ == 1
+	case 3:
+		return int64(int8(r.next(1)[0]))
+	case 4, thriftI32, thriftI64:
+		return r.varint()
+	case 7:
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.next(8)))
+	case thriftBinary:
+		return string(r.next(int(r.uvarint())))
+	case thriftList:
+		b := r.next(1)[0]
+		size := int(b >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.readValue(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("thrift: unsupported type")
+}
@@ -0,0 +1,249 @@
+package tabular
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ColumnType is the storage type chosen for an exported column
+type ColumnType int
+
+const (
+	ColumnString ColumnType = iota
+	ColumnInt64
+	ColumnFloat64
+	ColumnBool
+)
+
+// Column holds one exported column. Values are int64, float64, bool or
+// string according to Type, or nil for a missing value.
+type Column struct {
+	Name   string
+	Type   ColumnType
+	Values []interface{}
+}
+
+// Table is a flattened record array ready to be written in a tabular format
+type Table struct {
+	Name    string
+	Columns []*Column
+	Rows    int
+}
+
+// knownRecordKeys are the payload keys of the built-in shapes, in the order
+// they are preferred as the default export table
+var knownRecordKeys = []string{"measurements", "collisions", "quantum_measurements", "algorithms", "models", "metrics"}
+
+// RecordArrays returns the payload keys that hold arrays of objects, known
+// shapes first and the rest alphabetically
+func RecordArrays(payload map[string]interface{}) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range knownRecordKeys {
+		if isRecordArray(payload[key]) {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var others []string
+	for key, value := range payload {
+		if !seen[key] && isRecordArray(value) {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+
+	return append(keys, others...)
+}
+
+func isRecordArray(value interface{}) bool {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return false
+	}
+	_, ok = items[0].(map[string]interface{})
+	return ok
+}
+
+// Flatten turns the record array stored under key into a table. Nested
+// objects become dotted columns ("units.energy"); scalar arrays inside a
+// record are zipped into one row per index, so an algorithm with runtime
+// and input_size arrays yields one row per input size, with its scalar
+// fields repeated. Shorter arrays are padded with missing values.
+func Flatten(payload map[string]interface{}, key string) (*Table, error) {
+	items, ok := payload[key].([]interface{})
+	if !ok || !isRecordArray(payload[key]) {
+		return nil, fmt.Errorf("%q is not an array of records", key)
+	}
+
+	table := &Table{Name: key}
+	columns := map[string]*Column{}
+	column := func(name string) *Column {
+		c, ok := columns[name]
+		if !ok {
+			// Earlier rows did not have this column
+			c = &Column{Name: name, Values: make([]interface{}, table.Rows)}
+			columns[name] = c
+			table.Columns = append(table.Columns, c)
+		}
+		return c
+	}
+
+	for i, item := range items {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] is not an object", key, i)
+		}
+
+		scalars := map[string]interface{}{}
+		arrays := map[string][]interface{}{}
+		flattenRecord("", record, scalars, arrays)
+
+		rows := 1
+		for _, values := range arrays {
+			if len(values) > rows {
+				rows = len(values)
+			}
+		}
+
+		for _, name := range sortedKeys(scalars) {
+			c := column(name)
+			for r := 0; r < rows; r++ {
+				c.Values = append(c.Values, scalars[name])
+			}
+		}
+		for _, name := range sortedKeys(arrays) {
+			c := column(name)
+			values := arrays[name]
+			for r := 0; r < rows; r++ {
+				if r < len(values) {
+					c.Values = append(c.Values, values[r])
+				} else {
+					c.Values = append(c.Values, nil)
+				}
+			}
+		}
+
+		table.Rows += rows
+		for _, c := range table.Columns {
+			for len(c.Values) < table.Rows {
+				c.Values = append(c.Values, nil)
+			}
+		}
+	}
+
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("records in %q have no fields", key)
+	}
+
+	for _, c := range table.Columns {
+		c.inferType()
+	}
+	return table, nil
+}
+
+// flattenRecord splits a record into scalar fields and scalar arrays, using
+// dotted names for nested objects. Arrays that contain objects or arrays are
+// kept as JSON text.
+func flattenRecord(prefix string, record map[string]interface{}, scalars map[string]interface{}, arrays map[string][]interface{}) {
+	for key, value := range record {
+		name := prefix + key
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenRecord(name+".", v, scalars, arrays)
+		case []interface{}:
+			if isScalarArray(v) {
+				arrays[name] = v
+			} else {
+				encoded, _ := json.Marshal(v)
+				scalars[name] = string(encoded)
+			}
+		default:
+			scalars[name] = v
+		}
+	}
+}
+
+func isScalarArray(values []interface{}) bool {
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// inferType picks the narrowest type that holds every value and converts
+// the values to it
+func (c *Column) inferType() {
+	allBool, allNumber, allString, allIntegral := true, true, true, true
+	seen := false
+	for _, value := range c.Values {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case bool:
+			allNumber, allString = false, false
+		case float64:
+			allBool, allString = false, false
+			if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+				allIntegral = false
+			}
+		case string:
+			allBool, allNumber = false, false
+		default:
+			allBool, allNumber, allString = false, false, false
+		}
+		seen = true
+	}
+
+	switch {
+	case !seen:
+		c.Type = ColumnString
+	case allBool:
+		c.Type = ColumnBool
+	case allNumber && allIntegral:
+		c.Type = ColumnInt64
+		for i, value := range c.Values {
+			if value != nil {
+				c.Values[i] = int64(value.(float64))
+			}
+		}
+	case allNumber:
+		c.Type = ColumnFloat64
+	case allString:
+		c.Type = ColumnString
+	default:
+		c.Type = ColumnString
+		for i, value := range c.Values {
+			if _, ok := value.(string); value != nil && !ok {
+				encoded, _ := json.Marshal(value)
+				c.Values[i] = string(encoded)
+			}
+		}
+	}
+}
+
+// NullCount returns the number of missing values in the column
+func (c *Column) NullCount() int {
+	count := 0
+	for _, value := range c.Values {
+		if value == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"fresherpaint/backend/models"
+)
+
+// Refresh failures; both are reported to the client as 401
+var (
+	errRefreshInvalid = errors.New("refresh token is invalid or expired")
+	errRefreshReused  = errors.New("refresh token was already used")
+)
+
+// TokenPair is the response to a login or refresh
+type TokenPair struct {
+	Token            string       `json:"token"`
+	ExpiresAt        int64        `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt int64        `json:"refresh_expires_at"`
+	User             *models.User `json:"user"`
+}
+
+// RefreshRequest is the body of POST /api/auth/refresh and /api/auth/logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// newRefreshToken returns a random token and the hash stored for it
+func newRefreshToken() (token, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(bytes)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// storeRefreshToken saves a new refresh token in a family; an empty family
+// starts a new one
+func storeRefreshToken(q rowQuerier, userID, familyID string) (string, time.Time, error) {
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(authConfig.RefreshTokenTTL)
+	err = q.QueryRow(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, COALESCE(NULLIF($2, '')::uuid, gen_random_uuid()), $3, $4)
+		RETURNING family_id`,
+		userID, familyID, hash, expiresAt,
+	).Scan(&familyID)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// issueTokens signs an access token for the user and starts a new refresh
+// token family
+func issueTokens(user *models.User) (*TokenPair, error) {
+	access, expiresAt, err := signAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, refreshExpiresAt, err := storeRefreshToken(database.GetDB(), user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		Token:            access,
+		ExpiresAt:        expiresAt.Unix(),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExpiresAt.Unix(),
+		User:             user,
+	}, nil
+}
+
+// rotateRefreshToken exchanges a refresh token for a new access token and
+// the next refresh token of its family. Presenting a token that was already
+// exchanged means it has leaked, so its whole family is revoked.
+func rotateRefreshToken(token string) (*TokenPair, error) {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id, userID, familyID string
+	var expiresAt time.Time
+	var usedAt, revokedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`,
+		hashRefreshToken(token),
+	).Scan(&id, &userID, &familyID, &expiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errRefreshInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid || !expiresAt.After(time.Now()) {
+		return nil, errRefreshInvalid
+	}
+	if usedAt.Valid {
+		if _, err := tx.Exec(
+			"UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL", familyID,
+		); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		slog.Warn("Refresh token reused; revoked its token family", "user_id", userID, "family_id", familyID)
+		return nil, errRefreshReused
+	}
+
+	// The role is read again so role changes apply from the next refresh. A
+	// user deleted since the token was read leaves it unusable.
+	user, err := scanUser(tx.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errRefreshInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+	refresh, refreshExpiresAt, err := storeRefreshToken(tx, userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	access, accessExpiresAt, err := signAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		Token:            access,
+		ExpiresAt:        accessExpiresAt.Unix(),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExpiresAt.Unix(),
+		User:             user,
+	}, nil
+}
+
+// revokeRefreshFamily revokes the family a refresh token belongs to
+func revokeRefreshFamily(token string) error {
+	_, err := database.GetDB().Exec(`
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE revoked_at IS NULL
+		  AND family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)`,
+		hashRefreshToken(token),
+	)
+	return err
+}
+
+// refreshHandler serves POST /api/auth/refresh
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := decodeAuthBody(w, r, &req); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+	if req.RefreshToken == "" {
+		writeError(w, apiError(CodeBadRequest, "refresh_token is required"))
+		return
+	}
+
+	tokens, err := rotateRefreshToken(req.RefreshToken)
+	if errors.Is(err, errRefreshInvalid) || errors.Is(err, errRefreshReused) {
+		reason := "invalid"
+		if errors.Is(err, errRefreshReused) {
+			reason = "reused"
+		}
+		countTokenFailure("refresh", reason)
+		writeError(w, apiError(CodeInvalidToken, "Invalid refresh token"))
+		return
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to refresh token", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: tokens})
+}
+
+// logoutHandler serves POST /api/auth/logout. It revokes the bearer access
+// token, if one is sent and still valid, and the family of the refresh token
+// in the body, if any, so a client whose access token has already expired
+// can still log out.
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := decodeAuthBody(w, r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, invalidBody(err))
+		return
+	}
+
+	claims, _ := parseBearerToken(r)
+	if claims == nil && req.RefreshToken == "" {
+		writeError(w, apiError(CodeUnauthorized, "A valid access token or refresh_token is required"))
+		return
+	}
+
+	if claims != nil && claims.ID != "" {
+		if err := revocations.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+			writeError(w, internalError("Failed to log out", err))
+			return
+		}
+	}
+	if req.RefreshToken != "" {
+		if err := revokeRefreshFamily(req.RefreshToken); err != nil {
+			writeError(w, internalError("Failed to log out", err))
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]string{"status": "logged_out"}})
+}
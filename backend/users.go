@@ -0,0 +1,323 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"fresherpaint/backend/models"
+)
+
+const userColumns = "id, username, role, password_hash, created_at, updated_at"
+
+// errLastAdmin is returned when a change would leave no admin account
+var errLastAdmin = errors.New("the last admin cannot be deleted or demoted")
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var u models.User
+	if err := row.Scan(&u.ID, &u.Username, &u.Role, &u.PasswordHash, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// createUser stores a validated user with a bcrypt hash of its password
+func createUser(in *models.UserInput) (*models.User, error) {
+	hash, err := hashPassword(in.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	row := database.GetDB().QueryRow(
+		"INSERT INTO users (username, password_hash, role) VALUES ($1, $2, $3) RETURNING "+userColumns,
+		in.Username, hash, in.Role,
+	)
+	return scanUser(row)
+}
+
+// findUserByUsername returns the user with the given username, or
+// sql.ErrNoRows
+func findUserByUsername(username string) (*models.User, error) {
+	row := database.GetDB().QueryRow("SELECT "+userColumns+" FROM users WHERE username = $1",
+		strings.ToLower(strings.TrimSpace(username)))
+	return scanUser(row)
+}
+
+func getUser(id string) (*models.User, error) {
+	return scanUser(database.GetDB().QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+func listUsers() ([]models.User, error) {
+	rows, err := database.GetDB().Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// updateUser changes a user's password and/or role. Admin rows are locked
+// while checking that a demotion leaves another admin, so two admins cannot
+// demote each other concurrently. A role change ends the user's sessions at
+// once, so the old role stops applying; a new password ends them at their
+// next refresh.
+func updateUser(id string, patch *models.UserPatch) (*models.User, error) {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanUser(tx.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		return nil, err
+	}
+
+	hash, role := current.PasswordHash, current.Role
+	if patch.Password != nil {
+		if hash, err = hashPassword(*patch.Password); err != nil {
+			return nil, err
+		}
+		// Sessions started with the old password end at their next refresh
+		if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", id); err != nil {
+			return nil, err
+		}
+	}
+	applyRevocation := func() {}
+	if patch.Role != nil && *patch.Role != current.Role {
+		if current.Role == models.RoleAdmin {
+			if err := checkOtherAdmin(tx, id); err != nil {
+				return nil, err
+			}
+		}
+		role = *patch.Role
+		if applyRevocation, err = revocations.RevokeUser(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := scanUser(tx.QueryRow(
+		"UPDATE users SET password_hash = $2, role = $3, updated_at = NOW() WHERE id = $1 RETURNING "+userColumns,
+		id, hash, role,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	applyRevocation()
+	return updated, nil
+}
+
+// deleteUser removes a user and ends their sessions; it refuses to remove
+// the last admin
+func deleteUser(id string) (bool, error) {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var role models.Role
+	err = tx.QueryRow("SELECT role FROM users WHERE id = $1 FOR UPDATE", id).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if role == models.RoleAdmin {
+		if err := checkOtherAdmin(tx, id); err != nil {
+			return false, err
+		}
+	}
+
+	applyRevocation, err := revocations.RevokeUser(tx, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE id = $1", id); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	applyRevocation()
+	return true, nil
+}
+
+// checkOtherAdmin locks the admin rows and fails with errLastAdmin unless an
+// admin other than id exists
+func checkOtherAdmin(tx *sql.Tx, id string) error {
+	rows, err := tx.Query("SELECT id FROM users WHERE role = $1 AND id <> $2 FOR UPDATE", models.RoleAdmin, id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return errLastAdmin
+	}
+	return nil
+}
+
+// ensureAdminUser creates the first admin account from ADMIN_USERNAME and
+// ADMIN_PASSWORD when the users table is empty
+func ensureAdminUser(config *Config) error {
+	var count int
+	if err := database.GetDB().QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if config.AdminPassword == "" {
+		slog.Warn("No user accounts exist; set ADMIN_PASSWORD to create the first admin")
+		return nil
+	}
+
+	input := models.UserInput{Username: config.AdminUsername, Password: config.AdminPassword, Role: models.RoleAdmin}
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("invalid initial admin account: %w", err)
+	}
+	if _, err := createUser(&input); err != nil {
+		return fmt.Errorf("failed to create initial admin account: %w", err)
+	}
+
+	slog.Info("Created admin account", "username", input.Username)
+	return nil
+}
+
+// listUsersHandler serves GET /api/admin/users
+func listUsersHandler(w http.ResponseWriter, r *http.Request) {
+	users, err := listUsers()
+	if err != nil {
+		writeError(w, internalError("Failed to list users", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: users})
+}
+
+// createUserHandler serves POST /api/admin/users
+func createUserHandler(w http.ResponseWriter, r *http.Request) {
+	var input models.UserInput
+	if err := decodeDatasetBody(w, r, &input); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user, err := createUser(&input)
+	if isPQError(err, "23505") {
+		writeError(w, apiError(CodeConflict, "User %q already exists", input.Username))
+		return
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to create user", err))
+		return
+	}
+
+	w.Header().Set("Location", "/api/admin/users/"+user.ID)
+	writeJSON(w, http.StatusCreated, APIResponse{Success: true, Data: user})
+}
+
+// getUserHandler serves GET /api/admin/users/{id}
+func getUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r, "User")
+	if !ok {
+		return
+	}
+
+	user, err := getUser(id)
+	writeUserResult(w, user, err, "Failed to get user")
+}
+
+// updateUserHandler serves PATCH /api/admin/users/{id}
+func updateUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r, "User")
+	if !ok {
+		return
+	}
+
+	var patch models.UserPatch
+	if err := decodeDatasetBody(w, r, &patch); err != nil {
+		writeError(w, invalidBody(err))
+		return
+	}
+	if err := patch.Validate(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user, err := updateUser(id, &patch)
+	writeUserResult(w, user, err, "Failed to update user")
+}
+
+// deleteUserHandler serves DELETE /api/admin/users/{id}
+func deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(w, r, "User")
+	if !ok {
+		return
+	}
+
+	found, err := deleteUser(id)
+	if errors.Is(err, errLastAdmin) {
+		writeError(w, apiError(CodeConflict, "Cannot delete user: %v", err))
+		return
+	}
+	if err != nil {
+		writeError(w, internalError("Failed to delete user", err))
+		return
+	}
+	if !found {
+		writeError(w, notFound("User"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]string{"id": id}})
+}
+
+// writeUserResult writes a fetched or updated user, mapping a missing row to
+// 404 and a last-admin demotion to 409
+func writeUserResult(w http.ResponseWriter, user *models.User, err error, failure string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, notFound("User"))
+	case errors.Is(err, errLastAdmin):
+		writeError(w, apiError(CodeConflict, "Cannot change role: %v", err))
+	case err != nil:
+		writeError(w, internalError(failure, err))
+	default:
+		writeJSON(w, http.StatusOK, APIResponse{Success: true, Data: user})
+	}
+}
@@ -6,8 +6,9 @@ import DataVisualization from './components/DataVisualization'
 import LoginPrompt from './components/LoginPrompt'
 import AboutMe from './components/AboutMe'
 import AboutSite from './components/AboutSite'
-import { AnalyticsData } from './types/analytics'
-import { buildApiUrl } from './config/api'
+import { AnalyticsData, DatasetType } from './types/analytics'
+import { apiConfig, buildApiUrl } from './config/api'
+import { authFetch, clearAuth, hasSession, logout, saveAuth } from './config/auth'
 
 function App() {
   const [activeTab, setActiveTab] = useState<'dashboard' | 'physics' | 'cs' | 'about-me' | 'about-site'>(() => {
@@ -19,28 +20,13 @@ function App() {
     return 'dashboard'
   })
   const [analyticsData, setAnalyticsData] = useState<AnalyticsData[]>([])
+  const [datasetTypes, setDatasetTypes] = useState<DatasetType[]>([])
   const [loading, setLoading] = useState(true)
-  const [isAuthenticated, setIsAuthenticated] = useState(() => {
-    // Check if user has a valid JWT token
-    const savedAuth = localStorage.getItem('fresherpaint_auth')
-    if (savedAuth) {
-      try {
-        const authData = JSON.parse(savedAuth)
-        // Check if token exists and hasn't expired
-        const now = new Date().getTime() / 1000 // Convert to seconds
-        if (authData.token && authData.expiresAt && now < authData.expiresAt) {
-          return true
-        }
-      } catch (error) {
-        console.error('Error parsing auth data:', error)
-        localStorage.removeItem('fresherpaint_auth')
-      }
-    }
-    return false
-  })
+  // A session survives until its refresh token expires
+  const [isAuthenticated, setIsAuthenticated] = useState(hasSession)
   const [loginError, setLoginError] = useState('')
 
-  const handleLogin = async (password: string) => {
+  const handleLogin = async (username: string, password: string) => {
     try {
       setLoginError('')
       const response = await fetch(buildApiUrl('/auth/login'), {
@@ -48,19 +34,15 @@ function App() {
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ password }),
+        body: JSON.stringify({ username, password }),
       })
 
       const result = await response.json()
 
       if (result.success && result.data.token) {
         setIsAuthenticated(true)
-        // Save JWT token to localStorage
-        localStorage.setItem('fresherpaint_auth', JSON.stringify({
-          token: result.data.token,
-          expiresAt: result.data.expires_at,
-          timestamp: new Date().getTime()
-        }))
+        // Save the access and refresh tokens to localStorage
+        saveAuth(result.data)
       } else {
         setLoginError(result.error || 'Authentication failed. Please try again.')
       }
@@ -70,6 +52,12 @@ function App() {
     }
   }
 
+  const handleLogout = async () => {
+    await logout()
+    setAnalyticsData([])
+    setIsAuthenticated(false)
+  }
+
   const handleTabChange = (tab: 'dashboard' | 'physics' | 'cs' | 'about-me' | 'about-site') => {
     setActiveTab(tab)
     // Save tab preference to localStorage
@@ -83,28 +71,43 @@ function App() {
     // Fetch analytics data from backend with authentication
     const fetchData = async () => {
       try {
-        const savedAuth = localStorage.getItem('fresherpaint_auth')
-        if (!savedAuth) {
-          setIsAuthenticated(false)
-          return
+        const fetchPage = (cursor?: string) => {
+          const params = new URLSearchParams({ limit: '200' })
+          if (cursor) params.set('cursor', cursor)
+          return authFetch(`/analytics?${params}`, {
+            headers: { 'Content-Type': 'application/json' },
+          })
         }
-
-        const authData = JSON.parse(savedAuth)
-        const response = await fetch(buildApiUrl('/analytics'), {
-          headers: {
-            'Authorization': `Bearer ${authData.token}`,
-            'Content-Type': 'application/json',
-          },
-        })
+        const response = await fetchPage()
 
         if (response.status === 401) {
-          // Token is invalid or expired
-          localStorage.removeItem('fresherpaint_auth')
+          // The session is invalid or could not be refreshed
+          clearAuth()
           setIsAuthenticated(false)
           return
         }
 
+        // The dataset type list comes from the backend registry
+        const typesResponse = await authFetch(apiConfig.endpoints.datasetTypes, {
+          headers: { 'Content-Type': 'application/json' },
+        })
+        if (typesResponse.ok) {
+          const typesResult = await typesResponse.json()
+          if (typesResult.success) {
+            setDatasetTypes(typesResult.data)
+          }
+        }
+
         const result = await response.json()
+
+        // The list is paginated; follow next_cursor until every page is loaded
+        let nextCursor = result.success ? result.meta?.next_cursor : undefined
+        while (nextCursor) {
+          const page = await (await fetchPage(nextCursor)).json()
+          if (!page.success) break
+          result.data = result.data.concat(page.data)
+          nextCursor = page.meta?.next_cursor
+        }
         
         if (result.success) {
           console.log('Raw API data:', result.data) // Debug log
@@ -148,7 +151,7 @@ function App() {
 
   return (
     <div className="min-h-screen bg-gray-50">
-      <Header activeTab={activeTab} onTabChange={handleTabChange} />
+      <Header activeTab={activeTab} onTabChange={handleTabChange} onLogout={handleLogout} />
       
       <main className="w-full mx-auto">
         {loading ? (
@@ -173,7 +176,7 @@ function App() {
               exit={{ opacity: 0, y: -20 }}
               transition={{ duration: 0.3, ease: "easeInOut" }}
             >
-              {activeTab === 'dashboard' && <Dashboard data={analyticsData} />}
+              {activeTab === 'dashboard' && <Dashboard data={analyticsData} datasetTypes={datasetTypes} />}
               {(activeTab === 'physics' || activeTab === 'cs') && (
                 <DataVisualization 
                   data={filteredData} 
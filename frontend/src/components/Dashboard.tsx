@@ -1,15 +1,24 @@
 import React from 'react'
 import { motion } from 'framer-motion'
-import { AnalyticsData } from '../types/analytics'
-import { BeakerIcon, CpuChipIcon, ChartBarIcon, ClockIcon } from '@heroicons/react/24/outline'
+import { AnalyticsData, DatasetType } from '../types/analytics'
+import { BeakerIcon, CpuChipIcon, ChartBarIcon, ClockIcon, Squares2X2Icon } from '@heroicons/react/24/outline'
 
 interface DashboardProps {
   data: AnalyticsData[]
+  datasetTypes: DatasetType[]
 }
 
-const Dashboard: React.FC<DashboardProps> = ({ data }) => {
-  const physicsData = data.filter(item => item.dataType === 'physics')
-  const csData = data.filter(item => item.dataType === 'computer_science')
+// Built-in types keep their own icon and colours; registered types share a neutral style
+const typeStyles: Record<string, { icon: typeof BeakerIcon, color: string, badge: string }> = {
+  physics: { icon: BeakerIcon, color: 'text-blue-600', badge: 'bg-blue-100 text-blue-800' },
+  computer_science: { icon: CpuChipIcon, color: 'text-success-500', badge: 'bg-success-100 text-success-800' },
+}
+const defaultTypeStyle = { icon: Squares2X2Icon, color: 'text-accent-600', badge: 'bg-gray-200 text-gray-800' }
+
+const Dashboard: React.FC<DashboardProps> = ({ data, datasetTypes }) => {
+  const styleFor = (typeName: string) => typeStyles[typeName] || defaultTypeStyle
+  const displayName = (typeName: string) =>
+    datasetTypes.find(t => t.name === typeName)?.display_name || typeName
 
   const stats = [
     {
@@ -18,18 +27,12 @@ const Dashboard: React.FC<DashboardProps> = ({ data }) => {
       icon: ChartBarIcon,
       color: 'text-secondary-600'
     },
-    {
-      name: 'Physics Experiments',
-      value: physicsData.length,
-      icon: BeakerIcon,
-      color: 'text-blue-600'
-    },
-    {
-      name: 'CS Analytics',
-      value: csData.length,
-      icon: CpuChipIcon,
-      color: 'text-success-500'
-    },
+    ...datasetTypes.map(t => ({
+      name: t.display_name,
+      value: data.filter(item => item.dataType === t.name).length,
+      icon: styleFor(t.name).icon,
+      color: styleFor(t.name).color
+    })),
     {
       name: 'Last Updated',
       value: data.length > 0 ? new Date(Math.max(...data.map(d => d.updatedAt.getTime()))).toLocaleDateString() : 'N/A',
@@ -138,11 +141,9 @@ const Dashboard: React.FC<DashboardProps> = ({ data }) => {
                     whileHover={{ scale: 1.2, rotate: 10 }}
                     transition={{ duration: 0.2 }}
                   >
-                    {item.dataType === 'physics' ? (
-                      <BeakerIcon className="h-5 w-5 text-blue-600" />
-                    ) : (
-                      <CpuChipIcon className="h-5 w-5 text-success-500" />
-                    )}
+                    {React.createElement(styleFor(item.dataType).icon, {
+                      className: `h-5 w-5 ${styleFor(item.dataType).color}`
+                    })}
                   </motion.div>
                   <div>
                     <h4 className="font-medium text-gray-900">{item.title}</h4>
@@ -154,15 +155,11 @@ const Dashboard: React.FC<DashboardProps> = ({ data }) => {
                     {item.createdAt.toLocaleDateString()}
                   </p>
                   <motion.span 
-                    className={`inline-flex px-2 py-1 text-xs font-medium rounded-full ${
-                      item.dataType === 'physics' 
-                        ? 'bg-blue-100 text-blue-800' 
-                        : 'bg-success-100 text-success-800'
-                    }`}
+                    className={`inline-flex px-2 py-1 text-xs font-medium rounded-full ${styleFor(item.dataType).badge}`}
                     whileHover={{ scale: 1.05 }}
                     transition={{ duration: 0.15 }}
                   >
-                    {item.dataType === 'physics' ? 'Physics' : 'Computer Science'}
+                    {displayName(item.dataType)}
                   </motion.span>
                 </div>
               </motion.div>
@@ -2,6 +2,7 @@ import React from 'react'
 import { motion } from 'framer-motion'
 import { LineChart, Line, XAxis, YAxis, CartesianGrid, Tooltip, Legend, ResponsiveContainer, BarChart, Bar, ScatterChart, Scatter } from 'recharts'
 import { AnalyticsData, AnalyticsType, PhysicsData } from '../types/analytics'
+import HistogramChart from './HistogramChart'
 
 interface DataVisualizationProps {
   data: AnalyticsData[]
@@ -47,16 +48,11 @@ const DataVisualization: React.FC<DataVisualizationProps> = ({ data, type }) =>
               animate={{ opacity: 1, scale: 1 }}
               transition={{ duration: 0.5, delay: 0.4 }}
             >
-              <ResponsiveContainer width="100%" height={300}>
-                <BarChart data={chartData}>
-                  <CartesianGrid strokeDasharray="3 3" />
-                  <XAxis dataKey="index" label={{ value: 'Event #', position: 'insideBottom', offset: -10 }} />
-                  <YAxis label={{ value: `Invariant Mass (${physicsData.units?.energy || 'GeV'})`, angle: -90, position: 'insideLeft' }} />
-                  <Tooltip formatter={(value, name) => [value, name === 'invariant_mass' ? 'Invariant Mass (GeV)' : name]} />
-                  <Legend />
-                  <Bar dataKey="invariant_mass" fill="#1E3A8A" />
-                </BarChart>
-              </ResponsiveContainer>
+              <HistogramChart
+                datasetId={item.id}
+                path="measurements.invariant_mass"
+                label={`Invariant Mass (${physicsData.units?.energy || 'GeV'})`}
+              />
             </motion.div>
           </motion.div>
           
@@ -123,7 +119,11 @@ const DataVisualization: React.FC<DataVisualizationProps> = ({ data, type }) =>
             Quantum Entanglement Correlation vs Measurement Angle
             {physicsData.bell_parameter && (
               <div className="text-sm text-gray-600 mt-2">
-                Bell Parameter S = {physicsData.bell_parameter} (violates Bell inequality: S &gt; 2)
+                Bell Parameter S = {physicsData.bell_parameter}
+                {physicsData.bell_parameter_error !== undefined && <> ± {physicsData.bell_parameter_error}</>}
+                {physicsData.bell_violation_sigma !== undefined
+                  ? ` (${Math.abs(physicsData.bell_violation_sigma)}σ ${physicsData.bell_parameter > 2 ? 'above' : 'below'} the classical bound S = 2)`
+                  : physicsData.bell_parameter > 2 && ' (violates Bell inequality: S > 2)'}
               </div>
             )}
           </motion.h4>
@@ -368,18 +368,27 @@ const DataVisualization: React.FC<DataVisualizationProps> = ({ data, type }) =>
         return <div className="bg-white p-6 rounded-lg shadow-sm border border-gray-200 text-center"><p className="text-gray-500">No algorithm data available</p></div>
       }
 
+      // Measured benchmarks have one series per algorithm and input distribution
+      const seriesKey = (algo: any) => algo.distribution ? `${algo.name} (${algo.distribution})` : algo.name
+
       const chartData = inputSizes.map((size: number, index: number) => {
         const dataPoint: any = { inputSize: size }
         csData.algorithms?.forEach((algo: any) => {
           const runtime = algo.runtime || []
           if (runtime[index] !== undefined) {
-            dataPoint[algo.name] = runtime[index]
+            dataPoint[seriesKey(algo)] = runtime[index]
           }
         })
         return dataPoint
       })
 
-      const colors = ['#1E3A8A', '#DC2626', '#059669', '#7C3AED']
+      const env = csData.test_environment
+      const environment = env?.go_version
+        ? [env.cpu, env.cpu_cores && `${env.cpu_cores} cores`, env.go_version, env.os && env.arch && `${env.os}/${env.arch}`]
+        : [env?.cpu, env?.memory, [env?.compiler, env?.optimization].filter(Boolean).join(' ')]
+      const runtimeUnit = csData.runtime_unit === 'ms' ? 'milliseconds' : 'seconds'
+
+      const colors = ['#1E3A8A', '#DC2626', '#059669', '#7C3AED', '#D97706', '#0891B2', '#DB2777', '#4B5563']
 
       return (
         <motion.div 
@@ -396,9 +405,9 @@ const DataVisualization: React.FC<DataVisualizationProps> = ({ data, type }) =>
             transition={{ duration: 0.4, delay: 0.3 }}
           >
             Algorithm Performance Comparison
-            {csData.test_environment && (
+            {env && (
               <div className="text-sm text-gray-600 mt-2">
-                {csData.test_environment.cpu} | {csData.test_environment.memory} | {csData.test_environment.compiler} {csData.test_environment.optimization}
+                {environment.filter(Boolean).join(' | ')}
               </div>
             )}
           </motion.h4>
@@ -411,17 +420,17 @@ const DataVisualization: React.FC<DataVisualizationProps> = ({ data, type }) =>
               <LineChart data={chartData}>
                 <CartesianGrid strokeDasharray="3 3" />
                 <XAxis dataKey="inputSize" label={{ value: 'Input Size', position: 'insideBottom', offset: -10 }} />
-                <YAxis label={{ value: 'Runtime (seconds)', angle: -90, position: 'insideLeft' }} />
+                <YAxis label={{ value: `Runtime (${runtimeUnit})`, angle: -90, position: 'insideLeft' }} />
                 <Tooltip />
                 <Legend />
                 {csData.algorithms.map((algo: any, index: number) => (
                   <Line 
-                    key={algo.name}
+                    key={seriesKey(algo)}
                     type="monotone" 
-                    dataKey={algo.name} 
+                    dataKey={seriesKey(algo)} 
                     stroke={colors[index % colors.length]} 
                     strokeWidth={2}
-                    name={`${algo.name} (${algo.complexity || 'Unknown'})`}
+                    name={`${seriesKey(algo)} (${algo.complexity || 'Unknown'})`}
                   />
                 ))}
               </LineChart>
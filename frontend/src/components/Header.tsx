@@ -1,13 +1,14 @@
 import React, { useState } from 'react'
 import { motion, AnimatePresence } from 'framer-motion'
-import { ChartBarIcon, BeakerIcon, CpuChipIcon, ChevronDownIcon, UserIcon, InformationCircleIcon } from '@heroicons/react/24/outline'
+import { ChartBarIcon, BeakerIcon, CpuChipIcon, ChevronDownIcon, UserIcon, InformationCircleIcon, ArrowRightOnRectangleIcon } from '@heroicons/react/24/outline'
 
 interface HeaderProps {
   activeTab: 'dashboard' | 'physics' | 'cs' | 'about-me' | 'about-site'
   onTabChange: (tab: 'dashboard' | 'physics' | 'cs' | 'about-me' | 'about-site') => void
+  onLogout: () => void
 }
 
-const Header: React.FC<HeaderProps> = ({ activeTab, onTabChange }) => {
+const Header: React.FC<HeaderProps> = ({ activeTab, onTabChange, onLogout }) => {
   const [analyticsDropdownOpen, setAnalyticsDropdownOpen] = useState(false)
   const [aboutDropdownOpen, setAboutDropdownOpen] = useState(false)
 
@@ -190,6 +191,16 @@ const Header: React.FC<HeaderProps> = ({ activeTab, onTabChange }) => {
               </AnimatePresence>
             </div>
           </nav>
+
+          <motion.button
+            onClick={onLogout}
+            className="ml-auto flex items-center space-x-2 px-3 py-2 rounded-md text-sm font-medium text-gray-300 hover:text-white transition-colors"
+            whileHover={{ scale: 1.05 }}
+            transition={{ duration: 0.2 }}
+          >
+            <ArrowRightOnRectangleIcon className="h-4 w-4" />
+            <span>Sign out</span>
+          </motion.button>
         </div>
       </div>
     </motion.header>
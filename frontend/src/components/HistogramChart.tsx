@@ -0,0 +1,62 @@
+import React, { useEffect, useState } from 'react'
+import { BarChart, Bar, XAxis, YAxis, CartesianGrid, Tooltip, ResponsiveContainer } from 'recharts'
+import { authFetch } from '../config/auth'
+import { Histogram } from '../types/analytics'
+
+interface HistogramChartProps {
+  datasetId: string
+  path: string
+  label: string
+  bins?: string
+}
+
+// Renders a histogram binned by the backend, so every client shows the same bins
+const HistogramChart: React.FC<HistogramChartProps> = ({ datasetId, path, label, bins }) => {
+  const [histogram, setHistogram] = useState<Histogram | null>(null)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    const params = new URLSearchParams({ path })
+    if (bins) params.set('bins', bins)
+
+    authFetch(`/analytics/${datasetId}/histogram?${params}`)
+      .then(response => response.json())
+      .then(result => {
+        if (result.success) {
+          setHistogram(result.data)
+        } else {
+          setError(result.error)
+        }
+      })
+      .catch(err => setError(String(err)))
+  }, [datasetId, path, bins])
+
+  if (error) {
+    return <p className="text-gray-500 text-center">Histogram unavailable: {error}</p>
+  }
+  if (!histogram) {
+    return <p className="text-gray-500 text-center">Loading histogram…</p>
+  }
+
+  const chartData = histogram.centers.map((center, i) => ({
+    center: Number(center.toFixed(2)),
+    count: histogram.counts[i],
+  }))
+
+  return (
+    <ResponsiveContainer width="100%" height={300}>
+      <BarChart data={chartData} barCategoryGap={0}>
+        <CartesianGrid strokeDasharray="3 3" />
+        <XAxis dataKey="center" label={{ value: label, position: 'insideBottom', offset: -10 }} />
+        <YAxis label={{ value: 'Events / bin', angle: -90, position: 'insideLeft' }} />
+        <Tooltip formatter={(value, _name, entry) => {
+          const i = chartData.indexOf(entry.payload)
+          return [`${value} (+${histogram.error_high[i].toFixed(1)} / −${histogram.error_low[i].toFixed(1)})`, 'Events']
+        }} />
+        <Bar dataKey="count" fill="#1E3A8A" />
+      </BarChart>
+    </ResponsiveContainer>
+  )
+}
+
+export default HistogramChart
@@ -2,20 +2,21 @@ import React, { useState } from 'react'
 import { LockClosedIcon } from '@heroicons/react/24/outline'
 
 interface LoginPromptProps {
-  onLogin: (password: string) => void
+  onLogin: (username: string, password: string) => void
   error?: string
 }
 
 const LoginPrompt: React.FC<LoginPromptProps> = ({ onLogin, error }) => {
+  const [username, setUsername] = useState('')
   const [password, setPassword] = useState('')
   const [isSubmitting, setIsSubmitting] = useState(false)
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault()
-    if (!password.trim()) return
+    if (!username.trim() || !password.trim()) return
     
     setIsSubmitting(true) 
-    onLogin(password)
+    onLogin(username.trim(), password)
     setIsSubmitting(false)
   }
 
@@ -34,13 +35,33 @@ const LoginPrompt: React.FC<LoginPromptProps> = ({ onLogin, error }) => {
           Welcome Freshpaint team!
         </p>
         <p className="mt-1 text-center text-sm text-gray-600">
-          Please enter the password from my resume to access the analytics platform. Please reach out to me if you have any questions or issues.
+          Please sign in with the username and password from my resume to access the analytics platform. Please reach out to me if you have any questions or issues.
         </p>
       </div>
 
       <div className="mt-8 sm:mx-auto sm:w-full sm:max-w-md">
         <div className="bg-white py-8 px-4 shadow-lg sm:rounded-lg sm:px-10 border border-gray-200">
           <form className="space-y-6" onSubmit={handleSubmit}>
+            <div>
+              <label htmlFor="username" className="block text-sm font-medium text-gray-700">
+                Username
+              </label>
+              <div className="mt-1">
+                <input
+                  id="username"
+                  name="username"
+                  type="text"
+                  autoComplete="username"
+                  autoCapitalize="none"
+                  required
+                  value={username}
+                  onChange={(e) => setUsername(e.target.value)}
+                  className="appearance-none block w-full px-3 py-2 border border-gray-300 rounded-md placeholder-gray-400 focus:outline-none focus:ring-secondary-900 focus:border-secondary-900 sm:text-sm"
+                  placeholder="Enter username"
+                />
+              </div>
+            </div>
+
             <div>
               <label htmlFor="password" className="block text-sm font-medium text-gray-700">
                 Password
@@ -66,7 +87,7 @@ const LoginPrompt: React.FC<LoginPromptProps> = ({ onLogin, error }) => {
             <div>
               <button
                 type="submit"
-                disabled={isSubmitting || !password.trim()}
+                disabled={isSubmitting || !username.trim() || !password.trim()}
                 className="w-full flex justify-center py-2 px-4 border border-transparent rounded-md shadow-sm text-sm font-medium text-white bg-secondary-900 hover:bg-secondary-800 hover:shadow-lg hover:shadow-secondary-900/50 hover:-translate-y-1 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-secondary-900 disabled:opacity-50 disabled:cursor-not-allowed disabled:hover:transform-none disabled:hover:shadow-none transition-all duration-300 transform"
               >
                 {isSubmitting ? (
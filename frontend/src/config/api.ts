@@ -6,9 +6,12 @@ export const apiConfig = {
   endpoints: {
     health: '/health',
     login: '/auth/login',
+    refresh: '/auth/refresh',
+    logout: '/auth/logout',
     verify: '/auth/verify',
     analytics: '/analytics',
     analyticsByType: '/analytics/type',
+    datasetTypes: '/dataset-types',
   },
 };
 
@@ -0,0 +1,136 @@
+// Session storage and authenticated requests. Access tokens are short-lived;
+// authFetch refreshes them with the stored refresh token when they are about
+// to expire or the backend rejects them.
+import { apiConfig, buildApiUrl } from './api';
+
+const AUTH_KEY = 'fresherpaint_auth';
+
+// Refresh this many seconds before the access token expires
+const REFRESH_MARGIN = 30;
+
+export interface StoredAuth {
+  token: string;
+  expiresAt: number;
+  refreshToken?: string;
+  refreshExpiresAt?: number;
+  timestamp: number;
+}
+
+const now = () => new Date().getTime() / 1000;
+
+export const loadAuth = (): StoredAuth | null => {
+  const saved = localStorage.getItem(AUTH_KEY);
+  if (!saved) return null;
+  try {
+    return JSON.parse(saved);
+  } catch (error) {
+    console.error('Error parsing auth data:', error);
+    localStorage.removeItem(AUTH_KEY);
+    return null;
+  }
+};
+
+// saveAuth stores the token pair returned by login or refresh
+export const saveAuth = (data: { token: string; expires_at: number; refresh_token?: string; refresh_expires_at?: number }) => {
+  localStorage.setItem(AUTH_KEY, JSON.stringify({
+    token: data.token,
+    expiresAt: data.expires_at,
+    refreshToken: data.refresh_token,
+    refreshExpiresAt: data.refresh_expires_at,
+    timestamp: new Date().getTime(),
+  }));
+};
+
+export const clearAuth = () => localStorage.removeItem(AUTH_KEY);
+
+// hasSession reports whether the stored access or refresh token is still usable
+export const hasSession = (): boolean => {
+  const auth = loadAuth();
+  if (!auth) return false;
+  if (auth.token && auth.expiresAt && now() < auth.expiresAt) return true;
+  return !!auth.refreshToken && !!auth.refreshExpiresAt && now() < auth.refreshExpiresAt;
+};
+
+// Each refresh token works once: presenting it again revokes the whole
+// session. Concurrent requests in this tab share one refresh, and tabs take
+// turns through a Web Lock, so a tab that waited uses the tokens another tab
+// stored instead of sending the spent refresh token again.
+const REFRESH_LOCK = 'fresherpaint_refresh';
+
+let refreshing: Promise<boolean> | null = null;
+
+const withRefreshLock = (task: () => Promise<boolean>): Promise<boolean> =>
+  'locks' in navigator ? navigator.locks.request(REFRESH_LOCK, task) : task();
+
+export const refreshSession = (): Promise<boolean> => {
+  if (!refreshing) {
+    const spent = loadAuth()?.refreshToken;
+    refreshing = withRefreshLock(async () => {
+      const auth = loadAuth();
+      if (!auth?.refreshToken) return false;
+      if (auth.refreshToken !== spent && now() < auth.expiresAt - REFRESH_MARGIN) {
+        // Another tab refreshed while this one waited
+        return true;
+      }
+      try {
+        const response = await fetch(buildApiUrl(apiConfig.endpoints.refresh), {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ refresh_token: auth.refreshToken }),
+        });
+        const result = await response.json();
+        if (!response.ok || !result.success) {
+          // Keep a session another tab stored in the meantime
+          if (loadAuth()?.refreshToken === auth.refreshToken) clearAuth();
+          return false;
+        }
+        saveAuth(result.data);
+        return true;
+      } catch (error) {
+        console.error('Token refresh failed:', error);
+        return false;
+      }
+    }).finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// authFetch requests an API endpoint with the current access token,
+// refreshing it first when needed and retrying once after a 401
+export const authFetch = async (endpoint: string, init: RequestInit = {}): Promise<Response> => {
+  const auth = loadAuth();
+  if (auth && now() > auth.expiresAt - REFRESH_MARGIN) {
+    await refreshSession();
+  }
+
+  const send = () => {
+    const headers = new Headers(init.headers);
+    const current = loadAuth();
+    if (current) headers.set('Authorization', `Bearer ${current.token}`);
+    return fetch(buildApiUrl(endpoint), { ...init, headers });
+  };
+
+  const response = await send();
+  if (response.status === 401 && (await refreshSession())) {
+    return send();
+  }
+  return response;
+};
+
+// logout revokes the session on the backend and forgets it locally
+export const logout = async () => {
+  const auth = loadAuth();
+  clearAuth();
+  if (!auth) return;
+  try {
+    await fetch(buildApiUrl(apiConfig.endpoints.logout), {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', 'Authorization': `Bearer ${auth.token}` },
+      body: JSON.stringify({ refresh_token: auth.refreshToken }),
+    });
+  } catch (error) {
+    console.error('Logout failed:', error);
+  }
+};
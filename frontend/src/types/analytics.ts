@@ -1,4 +1,14 @@
-export type AnalyticsType = 'physics' | 'computer_science'
+// Dataset type names come from the backend registry (GET /api/dataset-types);
+// 'physics' and 'computer_science' are the built-in ones
+export type AnalyticsType = string
+
+export interface DatasetType {
+  name: string
+  display_name: string
+  description: string
+  data_schema?: object
+  created_at: string
+}
 
 export interface AnalyticsData {
   id: string
@@ -10,6 +20,25 @@ export interface AnalyticsData {
   updatedAt: Date
 }
 
+// Histogram computed by GET /api/analytics/{id}/histogram
+export interface Histogram {
+  dataset_id: string
+  path: string
+  binning: string
+  edges: number[]
+  centers: number[]
+  counts: number[]
+  errors: number[]
+  error_low: number[]
+  error_high: number[]
+  weighted: boolean
+  entries: number
+  sum_weights: number
+  underflow: number
+  overflow: number
+  skipped: number
+}
+
 export interface PhysicsData {
   // Higgs boson decay data
   measurements?: Array<{
@@ -48,6 +77,8 @@ export interface PhysicsData {
   luminosity?: string
   experiment_type?: string
   bell_parameter?: number
+  bell_parameter_error?: number
+  bell_violation_sigma?: number
   background_rate?: number
 }
 
@@ -59,7 +90,13 @@ export interface ComputerScienceData {
     input_size?: number[]
     inputSize?: number[] // Support both naming conventions
     complexity?: string
+    distribution?: string
+    runtime_min?: number[]
+    runtime_max?: number[]
+    runtime_iqr?: number[]
+    repetitions?: number
   }>
+  runtime_unit?: string
   // Machine learning training data
   models?: Array<{
     name: string
@@ -88,6 +125,10 @@ export interface ComputerScienceData {
     memory?: string
     compiler?: string
     optimization?: string
+    cpu_cores?: string
+    go_version?: string
+    os?: string
+    arch?: string
   }
   dataset?: string
   hardware?: string